@@ -0,0 +1 @@
+DROP TABLE IF EXISTS "studio_settings";
\ No newline at end of file
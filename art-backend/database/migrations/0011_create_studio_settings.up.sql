@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS "studio_settings" (
+    "id" SERIAL PRIMARY KEY,
+    "cancel_window_hours" INTEGER NOT NULL DEFAULT 12 CHECK ("cancel_window_hours" >= 0),
+    "created_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+/* Настройки студии хранятся одной строкой */
+INSERT INTO "studio_settings" ("id") VALUES (1) ON CONFLICT ("id") DO NOTHING;
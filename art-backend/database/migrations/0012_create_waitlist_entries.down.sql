@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS "idx_waitlist_user_id";
+DROP INDEX IF EXISTS "idx_waitlist_slot_queue";
+
+DROP TABLE IF EXISTS "waitlist_entries";
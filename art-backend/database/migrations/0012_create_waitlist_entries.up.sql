@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS "waitlist_entries" (
+    "id" SERIAL PRIMARY KEY,
+    "slot_id" INTEGER NOT NULL REFERENCES "activity_slots"("id") ON DELETE CASCADE,
+    "activity_id" INTEGER NOT NULL,
+    "user_id" INTEGER NOT NULL REFERENCES "users"("id") ON DELETE CASCADE,
+    "phone_number" VARCHAR(15) NOT NULL,
+    "parent_name" TEXT,
+    "number_of_kids" INTEGER NOT NULL CHECK ("number_of_kids" > 0),
+    "kids" jsonb NOT NULL,
+    "status" VARCHAR(20) NOT NULL DEFAULT 'waiting',
+    "record_id" INTEGER NULL,
+    "promoted_at" TIMESTAMP NULL,
+    "created_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    "deleted_at" TIMESTAMP NULL
+);
+
+/* Очередь слота читается по порядку постановки */
+CREATE INDEX IF NOT EXISTS "idx_waitlist_slot_queue" ON "waitlist_entries" ("slot_id", "created_at", "id") WHERE "status" = 'waiting' AND "deleted_at" IS NULL;
+CREATE INDEX IF NOT EXISTS "idx_waitlist_user_id" ON "waitlist_entries" ("user_id");
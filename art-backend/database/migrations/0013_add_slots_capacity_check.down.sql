@@ -0,0 +1 @@
+ALTER TABLE "activity_slots" DROP CONSTRAINT IF EXISTS "chk_activity_slots_booked_capacity";
\ No newline at end of file
@@ -0,0 +1,10 @@
+/* Ни один путь записи не должен занять больше мест, чем есть в слоте.
+   Старые переполненные слоты сначала расширяются до числа записанных, иначе отмена записи в них
+   нарушила бы ограничение, после чего ограничение проверяется и для всех существующих строк */
+UPDATE "activity_slots" SET "capacity" = "booked" WHERE "booked" > "capacity";
+
+ALTER TABLE "activity_slots"
+ADD CONSTRAINT "chk_activity_slots_booked_capacity"
+CHECK ("booked" <= "capacity") NOT VALID;
+
+ALTER TABLE "activity_slots" VALIDATE CONSTRAINT "chk_activity_slots_booked_capacity";
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS "idx_record_kids_sub_kid_id";
+DROP INDEX IF EXISTS "idx_record_kids_user_kid_id";
+DROP INDEX IF EXISTS "idx_record_kids_record_id";
+
+DROP TABLE IF EXISTS "record_kids";
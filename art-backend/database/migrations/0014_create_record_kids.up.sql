@@ -0,0 +1,21 @@
+/* Дети записи со ссылкой на профиль клиента. Пока дети хранятся в details записи, таблица только связывает
+   запись с профилями, перенос остальных детей и их данных делает следующая миграция */
+CREATE TABLE IF NOT EXISTS "record_kids" (
+    "id" SERIAL PRIMARY KEY,
+    "record_id" INTEGER NOT NULL REFERENCES "records"("id") ON DELETE CASCADE,
+    "slot_id" INTEGER NOT NULL,
+    "user_kid_id" INTEGER NULL REFERENCES "user_kids"("id") ON DELETE SET NULL,
+    "sub_kid_id" INTEGER NULL REFERENCES "sub_kids"("id") ON DELETE SET NULL,
+    "name" VARCHAR(100) NOT NULL,
+    "age" INTEGER NOT NULL DEFAULT 0,
+    "gender" VARCHAR(20) NOT NULL DEFAULT '',
+    "created_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    "deleted_at" TIMESTAMP NULL
+);
+
+CREATE INDEX IF NOT EXISTS "idx_record_kids_record_id" ON "record_kids" ("record_id");
+
+/* Для истории посещений конкретного ребёнка */
+CREATE INDEX IF NOT EXISTS "idx_record_kids_user_kid_id" ON "record_kids" ("user_kid_id");
+CREATE INDEX IF NOT EXISTS "idx_record_kids_sub_kid_id" ON "record_kids" ("sub_kid_id");
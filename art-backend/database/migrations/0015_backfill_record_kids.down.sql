@@ -0,0 +1,18 @@
+UPDATE "records" r SET "details" = jsonb_set(r."details", '{kids}', agg."kids")
+FROM (
+    SELECT "record_id", jsonb_agg(
+        jsonb_strip_nulls(jsonb_build_object('name', "name", 'age', "age", 'gender', "gender", 'user_kid_id', "user_kid_id"))
+        ORDER BY "id"
+    ) AS "kids"
+    FROM "record_kids"
+    GROUP BY "record_id"
+) agg
+WHERE agg."record_id" = r."id" AND NOT (r."details" ? 'kids');
+
+DROP INDEX IF EXISTS "uniq_record_kids_slot_user_kid";
+DROP INDEX IF EXISTS "uniq_record_kids_slot_kid";
+
+/* Связи с профилями остаются, как их вела предыдущая версия */
+DELETE FROM "record_kids" WHERE "user_kid_id" IS NULL;
+
+ALTER TABLE "record_kids" DROP COLUMN IF EXISTS "active";
@@ -0,0 +1,54 @@
+/* Дети отменённой записи и исторические дубли остаются в записи, но не занимают уникальный индекс слота */
+ALTER TABLE "record_kids" ADD COLUMN IF NOT EXISTS "active" BOOLEAN NOT NULL DEFAULT true;
+
+/* Перенос детей из JSONB записей. Переносятся все дети: ссылка на удалённый профиль обнуляется,
+   а ранее перенесённые связи с профилями заменяются полными строками */
+DELETE FROM "record_kids";
+
+INSERT INTO "record_kids" ("record_id", "slot_id", "user_kid_id", "sub_kid_id", "name", "age", "gender", "created_at", "updated_at", "deleted_at")
+SELECT
+    r."id",
+    r."slot_id",
+    uk."id",
+    r."sub_kid_id",
+    COALESCE(k.value->>'name', ''),
+    COALESCE((k.value->>'age')::INTEGER, 0),
+    COALESCE(k.value->>'gender', ''),
+    r."created_at",
+    r."updated_at",
+    r."deleted_at"
+FROM "records" r
+CROSS JOIN LATERAL jsonb_array_elements(
+    CASE WHEN jsonb_typeof(r."details"->'kids') = 'array' THEN r."details"->'kids' ELSE '[]'::jsonb END
+) WITH ORDINALITY AS k(value, ord)
+LEFT JOIN "user_kids" uk ON uk."id" = (k.value->>'user_kid_id')::INTEGER
+ORDER BY r."id", k.ord;
+
+/* Исторические дубли (раньше проверялся только первый ребёнок): место на слоте занимает первая запись ребёнка */
+UPDATE "record_kids" rk SET "active" = false
+FROM (
+    SELECT "id", ROW_NUMBER() OVER (PARTITION BY "slot_id", LOWER("name"), "age", "gender" ORDER BY "id") AS "n"
+    FROM "record_kids"
+    WHERE "deleted_at" IS NULL
+) d
+WHERE d."id" = rk."id" AND d."n" > 1;
+
+UPDATE "record_kids" rk SET "active" = false
+FROM (
+    SELECT "id", ROW_NUMBER() OVER (PARTITION BY "slot_id", "user_kid_id" ORDER BY "id") AS "n"
+    FROM "record_kids"
+    WHERE "deleted_at" IS NULL AND "active" AND "user_kid_id" IS NOT NULL
+) d
+WHERE d."id" = rk."id" AND d."n" > 1;
+
+/* Один и тот же ребёнок не может быть записан на слот дважды */
+CREATE UNIQUE INDEX IF NOT EXISTS "uniq_record_kids_slot_kid"
+ON "record_kids" ("slot_id", LOWER("name"), "age", "gender")
+WHERE "deleted_at" IS NULL AND "active";
+
+CREATE UNIQUE INDEX IF NOT EXISTS "uniq_record_kids_slot_user_kid"
+ON "record_kids" ("slot_id", "user_kid_id")
+WHERE "deleted_at" IS NULL AND "active" AND "user_kid_id" IS NOT NULL;
+
+/* Источник истины теперь record_kids */
+UPDATE "records" SET "details" = "details" - 'kids' WHERE "details" ? 'kids';
@@ -0,0 +1,3 @@
+ALTER TABLE "studio_settings" DROP COLUMN IF EXISTS "visits_by_attendance";
+ALTER TABLE "records" DROP COLUMN IF EXISTS "visit_counted";
+DROP TABLE IF EXISTS "attendances";
@@ -0,0 +1,29 @@
+CREATE TABLE IF NOT EXISTS "attendances" (
+    "id" SERIAL PRIMARY KEY,
+    "record_kid_id" INTEGER NOT NULL REFERENCES "record_kids"("id") ON DELETE CASCADE,
+    "record_id" INTEGER NOT NULL REFERENCES "records"("id") ON DELETE CASCADE,
+    "slot_id" INTEGER NOT NULL,
+    "user_kid_id" INTEGER NULL REFERENCES "user_kids"("id") ON DELETE SET NULL,
+    "sub_kid_id" INTEGER NULL REFERENCES "sub_kids"("id") ON DELETE SET NULL,
+    "slot_start" TIMESTAMP NOT NULL,
+    "status" VARCHAR(20) NOT NULL CHECK ("status" IN ('present', 'absent', 'late', 'excused')),
+    "note" TEXT NOT NULL DEFAULT '',
+    "marked_at" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "marked_by" INTEGER NOT NULL REFERENCES "users"("id"),
+    "created_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    "deleted_at" TIMESTAMP NULL
+);
+
+/* Одна отметка на ребёнка в записи, повторная отметка перезаписывает статус */
+CREATE UNIQUE INDEX IF NOT EXISTS "uniq_attendances_record_kid" ON "attendances" ("record_kid_id") WHERE "deleted_at" IS NULL;
+CREATE INDEX IF NOT EXISTS "idx_attendances_slot_id" ON "attendances" ("slot_id");
+CREATE INDEX IF NOT EXISTS "idx_attendances_user_kid_start" ON "attendances" ("user_kid_id", "slot_start");
+CREATE INDEX IF NOT EXISTS "idx_attendances_sub_kid_start" ON "attendances" ("sub_kid_id", "slot_start");
+CREATE INDEX IF NOT EXISTS "idx_attendances_slot_start" ON "attendances" ("slot_start");
+
+/* Списан ли за запись визит абонемента: при записи или при отметке посещения, в зависимости от настроек */
+ALTER TABLE "records" ADD COLUMN IF NOT EXISTS "visit_counted" BOOLEAN NOT NULL DEFAULT false;
+UPDATE "records" SET "visit_counted" = true WHERE "subscription_id" IS NOT NULL;
+
+ALTER TABLE "studio_settings" ADD COLUMN IF NOT EXISTS "visits_by_attendance" BOOLEAN NOT NULL DEFAULT false;
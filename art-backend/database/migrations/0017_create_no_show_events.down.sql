@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS "no_show_events";
+
+ALTER TABLE "studio_settings"
+    DROP COLUMN IF EXISTS "no_show_burns_visit",
+    DROP COLUMN IF EXISTS "no_show_limit",
+    DROP COLUMN IF EXISTS "no_show_period_days",
+    DROP COLUMN IF EXISTS "no_show_block_days",
+    DROP COLUMN IF EXISTS "late_cancel_as_no_show";
@@ -0,0 +1,22 @@
+ALTER TABLE "studio_settings"
+    ADD COLUMN IF NOT EXISTS "no_show_burns_visit" BOOLEAN NOT NULL DEFAULT true,
+    ADD COLUMN IF NOT EXISTS "no_show_limit" INTEGER NOT NULL DEFAULT 3 CHECK ("no_show_limit" >= 0),
+    ADD COLUMN IF NOT EXISTS "no_show_period_days" INTEGER NOT NULL DEFAULT 30 CHECK ("no_show_period_days" > 0),
+    ADD COLUMN IF NOT EXISTS "no_show_block_days" INTEGER NOT NULL DEFAULT 7 CHECK ("no_show_block_days" >= 0),
+    ADD COLUMN IF NOT EXISTS "late_cancel_as_no_show" BOOLEAN NOT NULL DEFAULT false;
+
+CREATE TABLE IF NOT EXISTS "no_show_events" (
+    "id" SERIAL PRIMARY KEY,
+    "user_id" INTEGER NOT NULL REFERENCES "users"("id") ON DELETE CASCADE,
+    "record_id" INTEGER NOT NULL,
+    "slot_id" INTEGER NOT NULL,
+    "reason" VARCHAR(20) NOT NULL CHECK ("reason" IN ('absent', 'late_cancel')),
+    "occurred_at" TIMESTAMP NOT NULL,
+    "created_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    "deleted_at" TIMESTAMP NULL
+);
+
+/* Неявка по записи засчитывается один раз */
+CREATE UNIQUE INDEX IF NOT EXISTS "uniq_no_show_events_record" ON "no_show_events" ("record_id") WHERE "deleted_at" IS NULL;
+CREATE INDEX IF NOT EXISTS "idx_no_show_events_user_occurred" ON "no_show_events" ("user_id", "occurred_at");
@@ -0,0 +1,3 @@
+ALTER TABLE "records" DROP COLUMN IF EXISTS "series_id";
+DROP TABLE IF EXISTS "booking_series_weeks";
+DROP TABLE IF EXISTS "booking_series";
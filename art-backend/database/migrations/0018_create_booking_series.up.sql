@@ -0,0 +1,37 @@
+CREATE TABLE IF NOT EXISTS "booking_series" (
+    "id" SERIAL PRIMARY KEY,
+    "user_id" INTEGER NOT NULL REFERENCES "users"("id") ON DELETE CASCADE,
+    "activity_id" INTEGER NOT NULL REFERENCES "activities"("id") ON DELETE CASCADE,
+    "template_id" INTEGER NOT NULL REFERENCES "schedule_templates"("id") ON DELETE CASCADE,
+    "phone_number" VARCHAR(15) NOT NULL,
+    "parent_name" TEXT,
+    "number_of_kids" INTEGER NOT NULL CHECK ("number_of_kids" > 0),
+    "kids" jsonb NOT NULL,
+    "start_date" DATE NOT NULL,
+    "weeks" INTEGER NOT NULL CHECK ("weeks" > 0),
+    "status" VARCHAR(20) NOT NULL DEFAULT 'active',
+    "created_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    "deleted_at" TIMESTAMP NULL
+);
+
+CREATE INDEX IF NOT EXISTS "idx_booking_series_user_id" ON "booking_series" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_booking_series_template_active" ON "booking_series" ("template_id") WHERE "status" = 'active' AND "deleted_at" IS NULL;
+
+/* Результат по каждой неделе серии: запись создана или неделя пропущена с причиной */
+CREATE TABLE IF NOT EXISTS "booking_series_weeks" (
+    "id" SERIAL PRIMARY KEY,
+    "series_id" INTEGER NOT NULL REFERENCES "booking_series"("id") ON DELETE CASCADE,
+    "slot_date" DATE NOT NULL,
+    "slot_id" INTEGER NULL,
+    "record_id" INTEGER NULL,
+    "status" VARCHAR(20) NOT NULL,
+    "reason" VARCHAR(50) NOT NULL DEFAULT '',
+    "created_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS "uniq_booking_series_weeks_date" ON "booking_series_weeks" ("series_id", "slot_date");
+
+ALTER TABLE "records" ADD COLUMN IF NOT EXISTS "series_id" INTEGER NULL REFERENCES "booking_series"("id") ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS "idx_records_series_id" ON "records" ("series_id");
@@ -0,0 +1,2 @@
+ALTER TABLE "studio_settings" DROP COLUMN IF EXISTS "hold_ttl_minutes";
+DROP TABLE IF EXISTS "slot_holds";
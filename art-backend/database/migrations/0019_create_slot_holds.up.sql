@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS "slot_holds" (
+    "id" SERIAL PRIMARY KEY,
+    "slot_id" INTEGER NOT NULL REFERENCES "activity_slots"("id") ON DELETE CASCADE,
+    "user_id" INTEGER NOT NULL REFERENCES "users"("id") ON DELETE CASCADE,
+    "number_of_kids" INTEGER NOT NULL CHECK ("number_of_kids" > 0),
+    "kids" jsonb NOT NULL,
+    "expires_at" TIMESTAMP NOT NULL,
+    "status" VARCHAR(20) NOT NULL DEFAULT 'active',
+    "record_id" INTEGER NULL,
+    "created_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    "deleted_at" TIMESTAMP NULL
+);
+
+/* Активные холды слота суммируются при каждой проверке вместимости */
+CREATE INDEX IF NOT EXISTS "idx_slot_holds_active" ON "slot_holds" ("slot_id", "expires_at") WHERE "status" = 'active' AND "deleted_at" IS NULL;
+CREATE INDEX IF NOT EXISTS "idx_slot_holds_user_id" ON "slot_holds" ("user_id");
+
+ALTER TABLE "studio_settings" ADD COLUMN IF NOT EXISTS "hold_ttl_minutes" INTEGER NOT NULL DEFAULT 10 CHECK ("hold_ttl_minutes" > 0);
@@ -0,0 +1,15 @@
+UPDATE "record_kids" rk
+SET "deleted_at" = COALESCE(r."status_changed_at", rk."updated_at", CURRENT_TIMESTAMP), "active" = true
+FROM "records" r
+WHERE r."id" = rk."record_id" AND r."status" IN ('cancelled_by_client', 'cancelled_by_studio') AND NOT rk."active";
+
+UPDATE "records"
+SET "deleted_at" = COALESCE("status_changed_at", "updated_at")
+WHERE "status" IN ('cancelled_by_client', 'cancelled_by_studio');
+
+DROP TABLE IF EXISTS "record_status_changes";
+
+ALTER TABLE "records"
+    DROP COLUMN IF EXISTS "status",
+    DROP COLUMN IF EXISTS "status_reason",
+    DROP COLUMN IF EXISTS "status_changed_at";
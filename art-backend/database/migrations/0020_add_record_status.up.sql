@@ -0,0 +1,32 @@
+ALTER TABLE "records"
+    ADD COLUMN IF NOT EXISTS "status" VARCHAR(30) NOT NULL DEFAULT 'confirmed'
+        CHECK ("status" IN ('pending', 'confirmed', 'cancelled_by_client', 'cancelled_by_studio', 'completed', 'no_show')),
+    ADD COLUMN IF NOT EXISTS "status_reason" TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS "status_changed_at" TIMESTAMP NULL;
+
+CREATE INDEX IF NOT EXISTS "idx_records_status" ON "records" ("status");
+
+CREATE TABLE IF NOT EXISTS "record_status_changes" (
+    "id" SERIAL PRIMARY KEY,
+    "record_id" INTEGER NOT NULL REFERENCES "records"("id") ON DELETE CASCADE,
+    "from_status" VARCHAR(30) NOT NULL,
+    "to_status" VARCHAR(30) NOT NULL,
+    "reason" TEXT NOT NULL DEFAULT '',
+    "changed_by" INTEGER NULL REFERENCES "users"("id") ON DELETE SET NULL,
+    "created_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS "idx_record_status_changes_record_id" ON "record_status_changes" ("record_id");
+
+/* Мягко удалённые записи становятся отменёнными студией, чтобы вернуться в историю */
+UPDATE "record_kids" rk
+SET "active" = false, "deleted_at" = NULL
+FROM "records" r
+WHERE r."id" = rk."record_id" AND r."deleted_at" IS NOT NULL AND rk."deleted_at" IS NOT NULL;
+
+UPDATE "records"
+SET "status" = 'cancelled_by_studio',
+    "status_reason" = 'deleted before status tracking',
+    "status_changed_at" = "deleted_at",
+    "deleted_at" = NULL
+WHERE "deleted_at" IS NOT NULL;
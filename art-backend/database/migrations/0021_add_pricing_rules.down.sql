@@ -0,0 +1,6 @@
+ALTER TABLE "studio_settings" DROP COLUMN IF EXISTS "member_discount_percent";
+ALTER TABLE "studio_settings" DROP COLUMN IF EXISTS "early_bird_discount_percent";
+ALTER TABLE "studio_settings" DROP COLUMN IF EXISTS "early_bird_days";
+ALTER TABLE "studio_settings" DROP COLUMN IF EXISTS "sibling_discount_percent";
+ALTER TABLE "activity_slots" DROP COLUMN IF EXISTS "price_override";
+ALTER TABLE "records" DROP COLUMN IF EXISTS "price_breakdown";
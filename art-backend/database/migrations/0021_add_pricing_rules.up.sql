@@ -0,0 +1,9 @@
+ALTER TABLE "records" ADD COLUMN IF NOT EXISTS "price_breakdown" jsonb NULL;
+
+/* Цена конкретного слота вместо цены занятия, NULL — цена занятия */
+ALTER TABLE "activity_slots" ADD COLUMN IF NOT EXISTS "price_override" INTEGER NULL CHECK ("price_override" >= 0);
+
+ALTER TABLE "studio_settings" ADD COLUMN IF NOT EXISTS "sibling_discount_percent" INTEGER NOT NULL DEFAULT 0 CHECK ("sibling_discount_percent" BETWEEN 0 AND 100);
+ALTER TABLE "studio_settings" ADD COLUMN IF NOT EXISTS "early_bird_days" INTEGER NOT NULL DEFAULT 0 CHECK ("early_bird_days" >= 0);
+ALTER TABLE "studio_settings" ADD COLUMN IF NOT EXISTS "early_bird_discount_percent" INTEGER NOT NULL DEFAULT 0 CHECK ("early_bird_discount_percent" BETWEEN 0 AND 100);
+ALTER TABLE "studio_settings" ADD COLUMN IF NOT EXISTS "member_discount_percent" INTEGER NOT NULL DEFAULT 0 CHECK ("member_discount_percent" BETWEEN 0 AND 100);
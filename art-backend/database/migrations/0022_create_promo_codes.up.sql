@@ -0,0 +1,34 @@
+CREATE TABLE IF NOT EXISTS "promo_codes" (
+    "id" SERIAL PRIMARY KEY,
+    "code" VARCHAR(50) NOT NULL,
+    "discount_type" VARCHAR(10) NOT NULL CHECK ("discount_type" IN ('percent', 'fixed')),
+    "value" INTEGER NOT NULL CHECK ("value" > 0),
+    "valid_from" TIMESTAMP NULL,
+    "valid_to" TIMESTAMP NULL,
+    "max_uses" INTEGER NOT NULL DEFAULT 0 CHECK ("max_uses" >= 0),
+    "per_user_limit" INTEGER NOT NULL DEFAULT 0 CHECK ("per_user_limit" >= 0),
+    "uses_count" INTEGER NOT NULL DEFAULT 0 CHECK ("uses_count" >= 0),
+    "activity_id" INTEGER NULL REFERENCES "activities"("id") ON DELETE CASCADE,
+    "subscription_type_id" INTEGER NULL REFERENCES "subscription_types"("id") ON DELETE CASCADE,
+    "is_active" BOOLEAN NOT NULL DEFAULT TRUE,
+    "created_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    "deleted_at" TIMESTAMP NULL,
+    CHECK ("discount_type" <> 'percent' OR "value" <= 100)
+);
+
+/* Код вводится клиентом без учёта регистра */
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_promo_codes_code" ON "promo_codes" (UPPER("code")) WHERE "deleted_at" IS NULL;
+
+CREATE TABLE IF NOT EXISTS "promo_redemptions" (
+    "id" SERIAL PRIMARY KEY,
+    "promo_code_id" INTEGER NOT NULL REFERENCES "promo_codes"("id") ON DELETE CASCADE,
+    "user_id" INTEGER NOT NULL REFERENCES "users"("id") ON DELETE CASCADE,
+    "record_id" INTEGER NULL REFERENCES "records"("id") ON DELETE SET NULL,
+    "subscription_id" INTEGER NULL REFERENCES "subscriptions"("id") ON DELETE SET NULL,
+    "discount" INTEGER NOT NULL DEFAULT 0,
+    "created_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS "idx_promo_redemptions_code_user" ON "promo_redemptions" ("promo_code_id", "user_id");
+CREATE INDEX IF NOT EXISTS "idx_promo_redemptions_record_id" ON "promo_redemptions" ("record_id");
@@ -0,0 +1,6 @@
+ALTER TABLE "schedule_templates" DROP CONSTRAINT IF EXISTS "chk_schedule_templates_age_range";
+ALTER TABLE "schedule_templates" DROP COLUMN IF EXISTS "max_age";
+ALTER TABLE "schedule_templates" DROP COLUMN IF EXISTS "min_age";
+ALTER TABLE "activities" DROP CONSTRAINT IF EXISTS "chk_activities_age_range";
+ALTER TABLE "activities" DROP COLUMN IF EXISTS "max_age";
+ALTER TABLE "activities" DROP COLUMN IF EXISTS "min_age";
@@ -0,0 +1,8 @@
+ALTER TABLE "activities" ADD COLUMN IF NOT EXISTS "min_age" INTEGER NULL CHECK ("min_age" >= 0);
+ALTER TABLE "activities" ADD COLUMN IF NOT EXISTS "max_age" INTEGER NULL CHECK ("max_age" >= 0);
+ALTER TABLE "activities" ADD CONSTRAINT "chk_activities_age_range" CHECK ("min_age" IS NULL OR "max_age" IS NULL OR "min_age" <= "max_age");
+
+/* Ограничения шаблона, если заданы, заменяют ограничения занятия для его слотов */
+ALTER TABLE "schedule_templates" ADD COLUMN IF NOT EXISTS "min_age" INTEGER NULL CHECK ("min_age" >= 0);
+ALTER TABLE "schedule_templates" ADD COLUMN IF NOT EXISTS "max_age" INTEGER NULL CHECK ("max_age" >= 0);
+ALTER TABLE "schedule_templates" ADD CONSTRAINT "chk_schedule_templates_age_range" CHECK ("min_age" IS NULL OR "max_age" IS NULL OR "min_age" <= "max_age");
@@ -0,0 +1,5 @@
+ALTER TABLE "users" DROP COLUMN IF EXISTS "is_guest";
+ALTER TABLE "records" DROP COLUMN IF EXISTS "created_by";
+ALTER TABLE "records" DROP COLUMN IF EXISTS "created_by_staff";
+DELETE FROM "records" WHERE "user_id" IS NULL;
+ALTER TABLE "records" ALTER COLUMN "user_id" SET NOT NULL;
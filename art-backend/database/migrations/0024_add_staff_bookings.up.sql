@@ -0,0 +1,7 @@
+/* Гость, записанный владельцем без аккаунта, не привязан к пользователю */
+ALTER TABLE "records" ALTER COLUMN "user_id" DROP NOT NULL;
+ALTER TABLE "records" ADD COLUMN IF NOT EXISTS "created_by_staff" BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE "records" ADD COLUMN IF NOT EXISTS "created_by" INTEGER NULL REFERENCES "users"("id") ON DELETE SET NULL;
+
+/* Облегчённый аккаунт клиента, заведённый владельцем. Становится обычным при регистрации с тем же телефоном */
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "is_guest" BOOLEAN NOT NULL DEFAULT FALSE;
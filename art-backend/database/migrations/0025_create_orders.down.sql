@@ -0,0 +1,2 @@
+ALTER TABLE "records" DROP COLUMN IF EXISTS "order_id";
+DROP TABLE IF EXISTS "orders";
@@ -0,0 +1,16 @@
+/* Заказ из корзины: несколько разовых записей, оформленных одной транзакцией */
+CREATE TABLE IF NOT EXISTS "orders" (
+    "id" SERIAL PRIMARY KEY,
+    "user_id" INTEGER NOT NULL REFERENCES "users"("id") ON DELETE CASCADE,
+    "phone_number" VARCHAR(15) NOT NULL,
+    "parent_name" TEXT,
+    "total_price" REAL NOT NULL DEFAULT 0,
+    "created_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    "deleted_at" TIMESTAMP NULL
+);
+
+CREATE INDEX IF NOT EXISTS "idx_orders_user_id" ON "orders" ("user_id");
+
+ALTER TABLE "records" ADD COLUMN IF NOT EXISTS "order_id" INTEGER NULL REFERENCES "orders"("id") ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS "idx_records_order_id" ON "records" ("order_id");
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS "receipts";
+DROP TABLE IF EXISTS "receipt_counters";
+ALTER TABLE "studio_settings" DROP COLUMN IF EXISTS "studio_tax_id";
+ALTER TABLE "studio_settings" DROP COLUMN IF EXISTS "studio_phone";
+ALTER TABLE "studio_settings" DROP COLUMN IF EXISTS "studio_address";
+ALTER TABLE "studio_settings" DROP COLUMN IF EXISTS "studio_name";
@@ -0,0 +1,25 @@
+/* Реквизиты студии для квитанций */
+ALTER TABLE "studio_settings" ADD COLUMN IF NOT EXISTS "studio_name" VARCHAR(200) NOT NULL DEFAULT '';
+ALTER TABLE "studio_settings" ADD COLUMN IF NOT EXISTS "studio_address" TEXT NOT NULL DEFAULT '';
+ALTER TABLE "studio_settings" ADD COLUMN IF NOT EXISTS "studio_phone" VARCHAR(30) NOT NULL DEFAULT '';
+ALTER TABLE "studio_settings" ADD COLUMN IF NOT EXISTS "studio_tax_id" VARCHAR(20) NOT NULL DEFAULT '';
+
+/* Счётчик номеров квитанций по годам. Строка блокируется на время выдачи номера,
+   поэтому номера идут без пропусков: откат транзакции откатывает и счётчик */
+CREATE TABLE IF NOT EXISTS "receipt_counters" (
+    "year" INTEGER PRIMARY KEY,
+    "last_number" INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS "receipts" (
+    "id" SERIAL PRIMARY KEY,
+    "number" VARCHAR(20) NOT NULL UNIQUE,
+    "year" INTEGER NOT NULL,
+    "seq" INTEGER NOT NULL,
+    "record_id" INTEGER NULL UNIQUE REFERENCES "records"("id") ON DELETE SET NULL,
+    "subscription_id" INTEGER NULL UNIQUE REFERENCES "subscriptions"("id") ON DELETE SET NULL,
+    "total" INTEGER NOT NULL,
+    "issued_at" TIMESTAMP NOT NULL,
+    "created_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE ("year", "seq")
+);
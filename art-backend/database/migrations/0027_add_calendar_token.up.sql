@@ -0,0 +1,3 @@
+/* Секрет в ссылке на личный календарь клиента: лента читается календарями без cookie */
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "calendar_token" VARCHAR(64) NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS "uniq_users_calendar_token" ON "users" ("calendar_token");
@@ -0,0 +1,15 @@
+/* История запусков фоновых задач планировщика */
+CREATE TABLE IF NOT EXISTS "job_runs" (
+    "id" SERIAL PRIMARY KEY,
+    "job_name" VARCHAR(50) NOT NULL,
+    "trigger" VARCHAR(20) NOT NULL,
+    "instance" VARCHAR(100) NOT NULL,
+    "status" VARCHAR(20) NOT NULL,
+    "started_at" TIMESTAMP NOT NULL,
+    "finished_at" TIMESTAMP NULL,
+    "slots_created" INTEGER NOT NULL DEFAULT 0,
+    "errors_raised" INTEGER NOT NULL DEFAULT 0,
+    "error" TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS "idx_job_runs_job_started" ON "job_runs" ("job_name", "started_at" DESC);
@@ -0,0 +1,16 @@
+/* Закрытия студии: праздники, каникулы, отмена отдельного занятия на период */
+CREATE TABLE IF NOT EXISTS "closures" (
+    "id" SERIAL PRIMARY KEY,
+    "title" VARCHAR(100) NOT NULL,
+    "start_date" DATE NOT NULL,
+    "end_date" DATE NOT NULL,
+    "activity_id" INTEGER NULL REFERENCES "activities"("id") ON DELETE CASCADE,
+    "recurring" BOOLEAN NOT NULL DEFAULT FALSE,
+    "source" VARCHAR(20) NOT NULL DEFAULT 'manual' CHECK ("source" IN ('manual', 'holiday')),
+    "created_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    "deleted_at" TIMESTAMP NULL,
+    CHECK ("end_date" >= "start_date")
+);
+
+CREATE INDEX IF NOT EXISTS "idx_closures_dates" ON "closures" ("start_date", "end_date") WHERE "deleted_at" IS NULL;
@@ -0,0 +1,2 @@
+ALTER TABLE "activities" DROP COLUMN IF EXISTS "working_hours";
+ALTER TABLE "studio_settings" DROP COLUMN IF EXISTS "working_hours";
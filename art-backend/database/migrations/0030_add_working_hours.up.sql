@@ -0,0 +1,6 @@
+/* Рабочие дни и часы студии по дням недели 1–7. По умолчанию будни без ограничения часов, как раньше */
+ALTER TABLE "studio_settings" ADD COLUMN IF NOT EXISTS "working_hours" JSONB NOT NULL
+    DEFAULT '{"1":{"open":"00:00","close":"24:00"},"2":{"open":"00:00","close":"24:00"},"3":{"open":"00:00","close":"24:00"},"4":{"open":"00:00","close":"24:00"},"5":{"open":"00:00","close":"24:00"}}';
+
+/* Свои часы занятия вместо часов студии, NULL — как у студии */
+ALTER TABLE "activities" ADD COLUMN IF NOT EXISTS "working_hours" JSONB NULL;
@@ -0,0 +1,10 @@
+ALTER TABLE "schedule_templates" DROP CONSTRAINT IF EXISTS "excl_schedule_templates_period";
+
+/* Откат упадёт, если остались шаблоны с одинаковым днём и временем в разных периодах — их нужно удалить вручную */
+DROP INDEX IF EXISTS "uniq_template";
+CREATE UNIQUE INDEX IF NOT EXISTS "uniq_template" ON "schedule_templates" ("activity_id", "day_of_week", "start_time");
+
+ALTER TABLE "schedule_templates" DROP CONSTRAINT IF EXISTS "chk_schedule_templates_period";
+ALTER TABLE "schedule_templates" DROP COLUMN IF EXISTS "season";
+ALTER TABLE "schedule_templates" DROP COLUMN IF EXISTS "valid_to";
+ALTER TABLE "schedule_templates" DROP COLUMN IF EXISTS "valid_from";
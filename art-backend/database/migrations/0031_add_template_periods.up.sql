@@ -0,0 +1,23 @@
+/* Период действия и сезон шаблона: осеннее и летнее расписание живут рядом */
+ALTER TABLE "schedule_templates" ADD COLUMN IF NOT EXISTS "valid_from" DATE NULL;
+ALTER TABLE "schedule_templates" ADD COLUMN IF NOT EXISTS "valid_to" DATE NULL;
+ALTER TABLE "schedule_templates" ADD COLUMN IF NOT EXISTS "season" VARCHAR(50) NOT NULL DEFAULT '';
+ALTER TABLE "schedule_templates" ADD CONSTRAINT "chk_schedule_templates_period"
+    CHECK ("valid_from" IS NULL OR "valid_to" IS NULL OR "valid_from" <= "valid_to");
+
+/* Один день и время теперь могут повторяться в разных периодах, индекс лишь не даёт завести точную копию шаблона */
+DROP INDEX IF EXISTS "uniq_template";
+CREATE UNIQUE INDEX IF NOT EXISTS "uniq_template"
+    ON "schedule_templates" ("activity_id", "day_of_week", "start_time", "season", COALESCE("valid_from", '-infinity'::date))
+    WHERE "deleted_at" IS NULL;
+
+/* Периоды шаблонов на один день и время не пересекаются. Бэкенд проверяет это заранее ради понятного ответа,
+   а ограничение не пропускает параллельные сохранения. Пустые границы — бесконечность */
+CREATE EXTENSION IF NOT EXISTS btree_gist;
+ALTER TABLE "schedule_templates" ADD CONSTRAINT "excl_schedule_templates_period"
+    EXCLUDE USING gist (
+        "activity_id" WITH =,
+        "day_of_week" WITH =,
+        "start_time" WITH =,
+        daterange("valid_from", "valid_to", '[]') WITH &&
+    ) WHERE ("deleted_at" IS NULL);
@@ -0,0 +1,2 @@
+/* Почему заявка снята с очереди без участия клиента */
+ALTER TABLE "waitlist_entries" ADD COLUMN IF NOT EXISTS "status_reason" VARCHAR(50) NOT NULL DEFAULT '';
@@ -0,0 +1,68 @@
+package export
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+var ErrUnknownFormat = errors.New("unknown export format")
+
+// Writer пишет таблицу построчно прямо в поток, не накапливая строки в памяти.
+// Значения — строки, числа, bool или time.Time
+type Writer interface {
+	WriteRow(values ...any) error
+	Flush() error // Отправляет накопленное в поток, чтобы клиент получал файл по мере выгрузки
+	Close() error // Дописывает окончание файла, сам поток не закрывает
+}
+
+// NewWriter создаёт writer формата csv или xlsx. sheet — имя листа в xlsx
+func NewWriter(format string, w io.Writer, sheet string) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w)
+	case FormatXLSX:
+		return newXLSXWriter(w, sheet)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownFormat, format)
+	}
+}
+
+func ContentType(format string) string {
+	if format == FormatXLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+func formatValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case time.Time:
+		if v.IsZero() {
+			return ""
+		}
+		return v.Format("2006-01-02 15:04")
+	case *time.Time:
+		if v == nil {
+			return ""
+		}
+		return formatValue(*v)
+	case bool:
+		if v {
+			return "так"
+		}
+		return "ні"
+	default:
+		return fmt.Sprint(v)
+	}
+}
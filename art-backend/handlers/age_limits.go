@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"art/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+// mergeAgeRange переносит в current только те границы возраста, которые клиент прислал.
+// Явный null снимает границу, отсутствующий ключ оставляет прежнюю
+func mergeAgeRange(current *models.AgeRange, input models.AgeRange, fields map[string]json.RawMessage) {
+	if hasJSONField(fields, "min_age") {
+		current.MinAge = input.MinAge
+	}
+	if hasJSONField(fields, "max_age") {
+		current.MaxAge = input.MaxAge
+	}
+}
+
+// slotAgeRange возвращает возрастные ограничения слота: шаблона, если они в нём заданы, иначе занятия
+func slotAgeRange(db *gorm.DB, activity models.Activity, slot models.ActivitySlot) (models.AgeRange, error) {
+	if slot.TemplateID == nil {
+		return activity.AgeRange, nil
+	}
+
+	var tmpl models.ScheduleTemplate
+	if err := db.First(&tmpl, *slot.TemplateID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return activity.AgeRange, nil
+		}
+		return activity.AgeRange, fmt.Errorf("failed to find slot template: %w", err)
+	}
+	if tmpl.AgeRange.IsSet() {
+		return tmpl.AgeRange, nil
+	}
+	return activity.AgeRange, nil
+}
+
+// kidOutsideAgeRange возвращает первого ребёнка, который не подходит занятию по возрасту
+func kidOutsideAgeRange(kids []models.Kid, ages models.AgeRange) *models.Kid {
+	for i := range kids {
+		if !ages.Allows(kids[i].Age) {
+			return &kids[i]
+		}
+	}
+	return nil
+}
+
+func respondAgeError(c *gin.Context, ages models.AgeRange, kid *models.Kid) {
+	c.JSON(ageErrorBody(ages, kid))
+}
+
+func ageErrorBody(ages models.AgeRange, kid *models.Kid) (int, gin.H) {
+	return http.StatusBadRequest, gin.H{
+		"error":    fmt.Sprintf("Заняття для дітей віком %s років, %s %d р. не підходить за віком", ages, kid.Name, kid.Age),
+		"reason":   "age_not_allowed",
+		"kid_name": kid.Name,
+		"min_age":  ages.MinAge,
+		"max_age":  ages.MaxAge,
+	}
+}
+
+// checkKidsAge сам отвечает клиенту отказом, если кто-то из детей не подходит слоту по возрасту
+func checkKidsAge(c *gin.Context, db *gorm.DB, slot models.ActivitySlot, kids []models.Kid) bool {
+	var activity models.Activity
+	if err := db.First(&activity, slot.ActivityID).Error; err != nil {
+		log.Error().Err(err).Uint("activity_id", slot.ActivityID).Msg("Activity not found")
+		c.JSON(http.StatusNotFound, gin.H{"error": "Activity not found"})
+		return false
+	}
+
+	ages, err := slotAgeRange(db, activity, slot)
+	if err != nil {
+		log.Error().Err(err).Uint("slot_id", slot.ID).Msg("Error loading age limits")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check age limits"})
+		return false
+	}
+
+	if kid := kidOutsideAgeRange(kids, ages); kid != nil {
+		log.Info().Uint("slot_id", slot.ID).Str("kid_name", kid.Name).Int("age", kid.Age).Msg("Booking refused by age limits")
+		respondAgeError(c, ages, kid)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,397 @@
+package handlers
+
+import (
+	"art/database"
+	"art/models"
+	"art/utils"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Список детей, записанных на слот, с текущими отметками посещения
+func GetSlotRoster() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		db := database.GetGormDB()
+
+		slot, ok := findActivitySlot(c, db)
+		if !ok {
+			return
+		}
+
+		roster, err := loadRoster(db, slot.ID)
+		if err != nil {
+			log.Error().Err(err).Uint("slot_id", slot.ID).Msg("Failed to load slot roster")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load roster"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"slot":   slot,
+			"roster": roster,
+		})
+	}
+}
+
+// Отметка посещения детей на слоте. Повторная отметка того же ребёнка перезаписывает статус
+func MarkAttendance() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req models.AttendanceRequest
+		var staff models.User
+		db := database.GetGormDB()
+		redisClient, err := database.GetRedis()
+		if err != nil {
+			log.Error().Err(err).Msg("Error getting redis")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get redis"})
+			return
+		}
+
+		if err := c.ShouldBindJSON(&req); err != nil {
+			log.Error().Err(err).Msg("Error binding json")
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+			return
+		}
+
+		slot, ok := findActivitySlot(c, db)
+		if !ok {
+			return
+		}
+
+		if time.Now().Before(slot.StartTime) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Відмітити відвідування можна після початку заняття"})
+			return
+		}
+
+		phoneNumber, _ := c.Get("phone_number")
+		if err := db.Where("phone_number = ?", phoneNumber).First(&staff).Error; err != nil {
+			log.Error().Err(err).Msgf("Error finding user with phone number: %v", phoneNumber)
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+
+		settings, err := loadStudioSettings(db)
+		if err != nil {
+			log.Error().Err(err).Msg("Error loading studio settings")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load studio settings"})
+			return
+		}
+
+		now := time.Now().UTC()
+		touchedRecords := make(map[uint]struct{})
+
+		tx := db.Begin()
+		for _, mark := range req.Marks {
+			var kid models.RecordKid
+			if err := tx.Where("id = ? AND slot_id = ? AND active", mark.RecordKidID, slot.ID).First(&kid).Error; err != nil { // Дети отменённых записей отметить нельзя
+				tx.Rollback()
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("Kid %d is not booked on this slot", mark.RecordKidID)})
+					return
+				}
+				log.Error().Err(err).Uint("record_kid_id", mark.RecordKidID).Msg("Error finding record kid")
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to find kid"})
+				return
+			}
+
+			var attendance models.Attendance
+			err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("record_kid_id = ?", kid.ID).
+				First(&attendance).Error
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				tx.Rollback()
+				log.Error().Err(err).Uint("record_kid_id", kid.ID).Msg("Error finding attendance")
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to mark attendance"})
+				return
+			}
+
+			attendance.RecordKidID = kid.ID
+			attendance.RecordID = kid.RecordID
+			attendance.SlotID = slot.ID
+			attendance.UserKidID = kid.UserKidID
+			attendance.SubKidID = kid.SubKidID
+			attendance.SlotStart = slot.StartTime
+			attendance.Status = mark.Status
+			attendance.Note = mark.Note
+			attendance.MarkedAt = now
+			attendance.MarkedBy = staff.ID
+
+			if err := tx.Save(&attendance).Error; err != nil {
+				tx.Rollback()
+				log.Error().Err(err).Uint("record_kid_id", kid.ID).Msg("Failed to save attendance")
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to mark attendance"})
+				return
+			}
+
+			touchedRecords[kid.RecordID] = struct{}{}
+		}
+
+		visitsChanged := false
+		for recordID := range touchedRecords {
+			if err := syncNoShow(tx, recordID, slot.StartTime); err != nil {
+				tx.Rollback()
+				log.Error().Err(err).Uint("record_id", recordID).Msg("Failed to sync no-show")
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to mark attendance"})
+				return
+			}
+
+			if !settings.VisitsByAttendance {
+				continue
+			}
+			changed, err := syncRecordVisit(tx, recordID, settings)
+			if err != nil {
+				tx.Rollback()
+				log.Error().Err(err).Uint("record_id", recordID).Msg("Failed to sync subscription visit")
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update subscription visits"})
+				return
+			}
+			visitsChanged = visitsChanged || changed
+		}
+
+		if err := tx.Commit().Error; err != nil {
+			log.Error().Err(err).Msg("Commit failed for mark attendance")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Transaction failed"})
+			return
+		}
+
+		log.Info().Uint("slot_id", slot.ID).Uint("marked_by", staff.ID).Int("marks", len(req.Marks)).Msg("Attendance marked")
+
+		if redisClient != nil && visitsChanged {
+			utils.InvalidateCache(c, "/subscriptions*", "subscriptions:all:*")
+		}
+
+		roster, err := loadRoster(db, slot.ID)
+		if err != nil {
+			log.Error().Err(err).Uint("slot_id", slot.ID).Msg("Failed to load slot roster")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load roster"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"slot":   slot,
+			"roster": roster,
+		})
+	}
+}
+
+// Журнал посещений с фильтрами по слоту, ребёнку, статусу и периоду
+func GetAttendance() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		db := database.GetGormDB()
+
+		query := db.Model(&models.Attendance{})
+		for _, param := range []string{"slot_id", "record_id", "user_kid_id", "sub_kid_id"} {
+			value := c.Query(param)
+			if value == "" {
+				continue
+			}
+			id, err := strconv.Atoi(value)
+			if err != nil || id < 1 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + param})
+				return
+			}
+			query = query.Where(param+" = ?", id)
+		}
+
+		if status := c.Query("status"); status != "" {
+			query = query.Where("status = ?", status)
+		}
+
+		var ok bool
+		if query, ok = filterAttendancePeriod(c, query); !ok {
+			return
+		}
+
+		var attendances []models.Attendance
+		if err := query.Order("slot_start DESC, id ASC").Find(&attendances).Error; err != nil {
+			log.Error().Err(err).Msg("Failed to get attendance")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch attendance"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"attendance": attendances})
+	}
+}
+
+// Посещения ребёнка из профиля клиента. Владелец видит любого ребёнка
+func GetKidAttendance() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var kid models.UserKid
+		var user models.User
+		db := database.GetGormDB()
+
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to get id")
+			c.JSON(http.StatusNotFound, gin.H{"error": "Invalid id of kid"})
+			return
+		}
+
+		phoneNumber, ok := c.Get("phone_number")
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		if err := db.Where("phone_number = ?", phoneNumber).First(&user).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+
+		query := db.Where("id = ?", id)
+		if user.Role != "owner" {
+			query = query.Where("user_id = ?", user.ID)
+		}
+		if err := query.First(&kid).Error; err != nil {
+			log.Error().Err(err).Msgf("Error finding kid by id: %d", id)
+			c.JSON(http.StatusNotFound, gin.H{"error": "Kid not found"})
+			return
+		}
+
+		attendanceQuery, ok := filterAttendancePeriod(c, db.Where("user_kid_id = ?", kid.ID))
+		if !ok {
+			return
+		}
+
+		var attendances []models.Attendance
+		if err := attendanceQuery.Order("slot_start DESC").Find(&attendances).Error; err != nil {
+			log.Error().Err(err).Msgf("Error finding attendance by kid id: %d", kid.ID)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch attendance"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"attendance": attendances})
+	}
+}
+
+// findActivitySlot достаёт слот из :activity_id/:slot_id и сам отвечает клиенту при ошибке
+func findActivitySlot(c *gin.Context, db *gorm.DB) (models.ActivitySlot, bool) {
+	var slot models.ActivitySlot
+
+	slotID, err := strconv.Atoi(c.Param("slot_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to fetch slot id"})
+		return slot, false
+	}
+	activityID, err := strconv.Atoi(c.Param("activity_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to fetch activity id"})
+		return slot, false
+	}
+
+	if err := db.Where("id = ? AND activity_id = ?", slotID, activityID).First(&slot).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Слот не найден"})
+			return slot, false
+		}
+		log.Error().Err(err).Msgf("Error finding slot by id: %d", slotID)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch slot"})
+		return slot, false
+	}
+	return slot, true
+}
+
+func filterAttendancePeriod(c *gin.Context, query *gorm.DB) (*gorm.DB, bool) {
+	from, to, err := utils.ParseDateRange(c.Query("from"), c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return query, false
+	}
+	if !from.IsZero() {
+		query = query.Where("slot_start >= ?", from)
+	}
+	if !to.IsZero() {
+		query = query.Where("slot_start < ?", to)
+	}
+	return query, true
+}
+
+func loadRoster(db *gorm.DB, slotID uint) ([]models.RosterEntry, error) {
+	var roster []models.RosterEntry
+	err := db.Table("record_kids rk").
+		Select(`rk.id AS record_kid_id, rk.record_id, rk.name, rk.age, rk.gender, rk.user_kid_id, rk.sub_kid_id,
+			r.parent_name, r.phone_number, a.status, a.marked_at, a.marked_by`).
+		Joins("JOIN records r ON r.id = rk.record_id AND r.deleted_at IS NULL").
+		Joins("LEFT JOIN attendances a ON a.record_kid_id = rk.id AND a.deleted_at IS NULL").
+		Where("rk.slot_id = ? AND rk.active AND rk.deleted_at IS NULL", slotID).
+		Order("rk.name ASC, rk.id ASC").
+		Scan(&roster).Error
+	return roster, err
+}
+
+// syncRecordVisit списывает визит абонемента, если хотя бы один ребёнок записи пришёл
+// (или не пришёл без причины при NoShowBurnsVisit), и возвращает его, если отметку сняли.
+// Завершённая запись списывает визит всегда, неявка — только при NoShowBurnsVisit.
+// Возвращает true, если visits_used изменился
+func syncRecordVisit(tx *gorm.DB, recordID uint, settings models.StudioSettings) (bool, error) {
+	var record models.Record
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&record, recordID).Error; err != nil {
+		return false, fmt.Errorf("failed to lock record: %w", err)
+	}
+	if record.SubscriptionID == nil {
+		return false, nil
+	}
+
+	chargeable := []string{models.AttendancePresent, models.AttendanceLate}
+	if settings.NoShowBurnsVisit {
+		chargeable = append(chargeable, models.AttendanceAbsent)
+	}
+
+	var charged int64
+	if err := tx.Model(&models.Attendance{}).
+		Where("record_id = ? AND status IN ?", record.ID, chargeable).
+		Count(&charged).Error; err != nil {
+		return false, fmt.Errorf("failed to count attendance: %w", err)
+	}
+
+	// Итог занятия, выставленный по отметкам или владельцем вручную, важнее отдельных отметок
+	switch record.Status {
+	case models.RecordStatusCompleted:
+		charged = 1
+	case models.RecordStatusNoShow:
+		charged = 0
+		if settings.NoShowBurnsVisit {
+			charged = 1
+		}
+	}
+
+	if (charged > 0) == record.VisitCounted {
+		return false, nil
+	}
+
+	if charged > 0 {
+		if err := chargeVisit(tx, &record); err != nil {
+			return false, err
+		}
+		return record.VisitCounted, nil
+	}
+
+	var subscription models.Subscription
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&subscription, *record.SubscriptionID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Warn().Uint("subscription_id", *record.SubscriptionID).Msg("subscription missing, skipping visit sync")
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to lock subscription: %w", err)
+	}
+
+	changed := false
+	if subscription.VisitsUsed > 0 {
+		if err := tx.Model(&subscription).UpdateColumn("visits_used", gorm.Expr("visits_used - 1")).Error; err != nil {
+			return false, fmt.Errorf("failed to update subscription visits: %w", err)
+		}
+		changed = true
+	} else {
+		log.Warn().Uint("subscription_id", subscription.ID).Msg("VisitsUsed already 0, skipping decrement")
+	}
+	if err := tx.Model(&record).UpdateColumn("visit_counted", false).Error; err != nil {
+		return false, fmt.Errorf("failed to update record visit flag: %w", err)
+	}
+	return changed, nil
+}
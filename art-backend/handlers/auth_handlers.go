@@ -5,6 +5,7 @@ import (
 	"art/middleware"
 	"art/models"
 	"context"
+	"errors"
 	"regexp"
 
 	"net/http"
@@ -15,6 +16,7 @@ import (
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/rs/zerolog/log"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm/clause"
 )
 
 var (
@@ -90,7 +92,44 @@ func Register(c *gin.Context) {
 		}
 	}()
 
-	if err := tx.Create(&user).Error; err != nil {
+	// Облегчённый аккаунт, заведённый владельцем на этот телефон, становится полноценным,
+	// только если клиент подтвердил телефон кодом от студии
+	var guest models.User
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("phone_number = ? AND is_guest", user.PhoneNumber).First(&guest).Error; err == nil {
+		if err := checkGuestClaim(c, guest.ID, strings.TrimSpace(input.ClaimCode)); err != nil {
+			tx.Rollback()
+			switch {
+			case errors.Is(err, errGuestClaimRequired):
+				c.JSON(http.StatusConflict, gin.H{
+					"error":  "На цей номер студія вже створила акаунт. Попросіть у студії код підтвердження",
+					"reason": "guest_account_exists",
+				})
+			case errors.Is(err, errGuestClaimInvalid):
+				c.JSON(http.StatusForbidden, gin.H{
+					"error":  "Невірний або прострочений код підтвердження",
+					"reason": "invalid_claim_code",
+				})
+			default:
+				log.Error().Err(err).Msg("Failed to check guest claim code")
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка создания пользователя"})
+			}
+			return
+		}
+
+		if err := tx.Model(&guest).Updates(map[string]interface{}{
+			"username": user.Username,
+			"password": user.Password,
+			"name":     user.Name,
+			"surname":  user.Surname,
+			"is_guest": false,
+		}).Error; err != nil {
+			tx.Rollback()
+			log.Error().Err(err).Msg("Failed to upgrade guest account")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка создания пользователя"})
+			return
+		}
+		user.ID = guest.ID
+	} else if err := tx.Create(&user).Error; err != nil {
 		tx.Rollback()
 		log.Error().Err(err).Msg("Failed to create user")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка создания пользователя"})
@@ -103,6 +142,10 @@ func Register(c *gin.Context) {
 		return
 	}
 
+	if guest.ID != 0 { // Код одноразовый
+		dropGuestClaim(c, guest.ID)
+	}
+
 	log.Info().Str("user_username", user.Username).Msg("Create user")
 
 	expirationTime := time.Now().Add(24 * time.Hour)
@@ -0,0 +1,215 @@
+package handlers
+
+import (
+	"art/models"
+	"art/pricing"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+var (
+	errActivityNotFound     = errors.New("activity not found")
+	errSlotActivityMismatch = errors.New("slot does not belong to this activity")
+	errPromoRequiresAccount = errors.New("promo code requires client account")
+)
+
+type ageLimitError struct {
+	Ages models.AgeRange
+	Kid  models.Kid
+}
+
+func (e *ageLimitError) Error() string {
+	return fmt.Sprintf("kid %s (%d) is outside age range %s", e.Kid.Name, e.Kid.Age, e.Ages)
+}
+
+type duplicateKidError struct {
+	Kid *models.Kid // Пустой, если дубль поймал уникальный индекс
+}
+
+func (e *duplicateKidError) Error() string {
+	return "record for this kid on this slot already exist"
+}
+
+// Разовая запись на слот: от клиента через /record или от владельца за клиента
+type slotBooking struct {
+	UserID      *uint // Пустой у гостя без аккаунта
+	PhoneNumber string
+	ParentName  string
+	ActivityID  uint
+	SlotID      uint
+	Kids        []models.Kid
+	PromoCode   string
+	ByStaff     bool      // Запись оформил сотрудник
+	CreatedBy   *uint     // Какой именно, если удалось определить
+	OrderID     *uint     // Заказ из корзины, в составе которого создаётся запись
+	HoldID      uint      // Холд, места которого превращаются в запись и не считаются занятыми
+	QuotedAt    time.Time // На какой момент считается цена, пустое — на момент записи
+}
+
+// createSlotRecord бронирует места и создаёт запись внутри переданной транзакции.
+// Слот и промокод остаются заблокированными до коммита
+func createSlotRecord(tx *gorm.DB, b slotBooking) (models.Record, models.ActivitySlot, error) {
+	var record models.Record
+	numberOfKids := uint(len(b.Kids))
+
+	var activity models.Activity
+	if err := tx.First(&activity, b.ActivityID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return record, models.ActivitySlot{}, errActivityNotFound
+		}
+		return record, models.ActivitySlot{}, fmt.Errorf("failed to find activity: %w", err)
+	}
+
+	// Слот блокируется до коммита, поэтому параллельные запросы не могут превысить вместимость
+	slot, err := reserveHeldSeats(tx, b.SlotID, int(numberOfKids), b.HoldID)
+	if err != nil {
+		return record, slot, err
+	}
+
+	if slot.ActivityID != b.ActivityID {
+		return record, slot, errSlotActivityMismatch
+	}
+
+	ages, err := slotAgeRange(tx, activity, slot)
+	if err != nil {
+		return record, slot, err
+	}
+	if kid := kidOutsideAgeRange(b.Kids, ages); kid != nil {
+		return record, slot, &ageLimitError{Ages: ages, Kid: *kid}
+	}
+
+	now := time.Now().UTC()
+
+	var userID uint
+	if b.UserID != nil {
+		userID = *b.UserID
+	}
+
+	var promo models.PromoCode
+	var extraRules []pricing.Rule
+	if b.PromoCode != "" {
+		if b.UserID == nil {
+			return record, slot, errPromoRequiresAccount
+		}
+		promo, err = lockPromoCode(tx, b.PromoCode, userID, activity.ID, nil, now)
+		if err != nil {
+			return record, slot, err
+		}
+		extraRules = append(extraRules, promoRule(promo))
+	}
+
+	// Рассчитываем общую сумму по правилам цены студии
+	quotedAt := now
+	if !b.QuotedAt.IsZero() {
+		quotedAt = b.QuotedAt
+	}
+	price, err := quoteRecord(tx, userID, activity, slot, numberOfKids, quotedAt, extraRules...)
+	if err != nil {
+		return record, slot, err
+	}
+
+	duplicate, err := findDuplicateKid(tx, b.SlotID, b.Kids)
+	if err != nil {
+		return record, slot, fmt.Errorf("failed to check duplicate kid: %w", err)
+	}
+	if duplicate != nil {
+		return record, slot, &duplicateKidError{Kid: duplicate}
+	}
+
+	record = models.Record{
+		UserID:         b.UserID,
+		PhoneNumber:    b.PhoneNumber,
+		ParentName:     b.ParentName,
+		TotalPrice:     price.Total,
+		PriceBreakdown: &price,
+		SlotID:         b.SlotID,
+		CreatedByStaff: b.ByStaff,
+		CreatedBy:      b.CreatedBy,
+		OrderID:        b.OrderID,
+		Details: models.RecordDetail{
+			ActivityID:   b.ActivityID,
+			ActivityName: activity.Name,
+			NumberOfKids: numberOfKids,
+			Kids:         b.Kids,
+			Date:         slot.StartTime.UTC(),
+		},
+		Kids: models.NewRecordKids(b.SlotID, b.Kids), // Сохраняются в record_kids вместе с записью
+	}
+	record.CreatedAt = now
+
+	log.Info().Any("record", record).Msg("Creating record") // Логируем заказ перед сохранением
+
+	if err := tx.Create(&record).Error; err != nil {
+		if isUniqueViolation(err) { // Параллельная запись того же ребёнка поймана уникальным индексом record_kids
+			return record, slot, &duplicateKidError{}
+		}
+		return record, slot, fmt.Errorf("failed to create record: %w", err)
+	}
+
+	if b.PromoCode != "" {
+		if err := redeemPromo(tx, &promo, models.PromoRedemption{
+			UserID:   userID,
+			RecordID: &record.ID,
+			Discount: price.Discount(pricing.PromoRuleName),
+		}); err != nil {
+			return record, slot, err
+		}
+	}
+
+	return record, slot, nil
+}
+
+// respondBookingError переводит ошибку createSlotRecord в ответ клиенту
+func respondBookingError(c *gin.Context, slot models.ActivitySlot, err error) {
+	c.JSON(bookingErrorBody(slot, err))
+}
+
+// bookingErrorBody возвращает код и тело ответа отдельно, чтобы корзина могла дополнить их номером позиции
+func bookingErrorBody(slot models.ActivitySlot, err error) (int, gin.H) {
+	var ageErr *ageLimitError
+	var dupErr *duplicateKidError
+
+	switch {
+	case errors.Is(err, errSlotNotFound), errors.Is(err, errSlotInPast), errors.Is(err, errSlotFull):
+		return reserveErrorBody(slot, err) // При нехватке мест клиенту предлагается лист ожидания
+	case errors.Is(err, errActivityNotFound):
+		return http.StatusNotFound, gin.H{"error": "Activity not found"}
+	case errors.Is(err, errSlotActivityMismatch):
+		return http.StatusBadRequest, gin.H{"error": "Slot does not belong to this activity"}
+	case errors.As(err, &ageErr):
+		return ageErrorBody(ageErr.Ages, &ageErr.Kid)
+	case errors.As(err, &dupErr):
+		log.Warn().Err(err).Msg("Duplicate kid on slot")
+		resp := gin.H{"error": "Record for this kid on this slot already exist"}
+		if dupErr.Kid != nil {
+			resp["kid_name"] = dupErr.Kid.Name
+		}
+		return http.StatusConflict, resp
+	case errors.Is(err, errPromoRequiresAccount):
+		return http.StatusBadRequest, gin.H{"error": "Промокод доступний лише клієнтам з акаунтом", "reason": "promo_requires_account"}
+	case errors.Is(err, errPromoNotFound), errors.Is(err, errPromoNotValid), errors.Is(err, errPromoExhausted),
+		errors.Is(err, errPromoUserLimit), errors.Is(err, errPromoNotApplicable):
+		return promoErrorBody(err)
+	default:
+		log.Error().Err(err).Msg("Error creating record")
+		return http.StatusInternalServerError, gin.H{"error": "Error to create record"}
+	}
+}
+
+func recordCreatedResponse(record models.Record) gin.H {
+	return gin.H{
+		"record_id":       record.ID,
+		"created_at":      record.CreatedAt.Format(time.RFC3339),
+		"Details":         record.Details,
+		"total_price":     record.TotalPrice,
+		"price_breakdown": record.PriceBreakdown,
+		"phone_number":    record.PhoneNumber,
+		"parent_name":     record.ParentName,
+	}
+}
@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"art/database"
+	"art/models"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Интеграционные тесты: нужен настоящий Postgres, параметры берутся из тех же DB_* переменных, что и у сервера
+func testDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	if os.Getenv("DB_HOST") == "" {
+		t.Skip("DB_HOST is not set, skipping integration test")
+	}
+	if database.GORMDB == nil {
+		if err := database.InitDB(); err != nil {
+			t.Fatalf("failed to init db: %v", err)
+		}
+	}
+	return database.GetGormDB()
+}
+
+// newTestSlot создаёт занятие со слотом через двое суток и удаляет их вместе с записями после теста
+func newTestSlot(t *testing.T, db *gorm.DB, capacity int) models.ActivitySlot {
+	t.Helper()
+
+	activity := models.Activity{
+		Name:        fmt.Sprintf("%s-%d", t.Name(), time.Now().UnixNano()),
+		Description: "integration test",
+		Images:      models.ActivityImage{MainImageURL: "test"},
+		Price:       100,
+		Duration:    60,
+	}
+	if err := db.Create(&activity).Error; err != nil {
+		t.Fatalf("failed to create activity: %v", err)
+	}
+
+	start := time.Now().UTC().Add(48 * time.Hour).Truncate(time.Minute)
+	slot := models.ActivitySlot{
+		ActivityID: activity.ID,
+		StartTime:  start,
+		EndTime:    start.Add(time.Hour),
+		Capacity:   capacity,
+		Source:     "manual",
+	}
+	if err := db.Create(&slot).Error; err != nil {
+		t.Fatalf("failed to create slot: %v", err)
+	}
+
+	t.Cleanup(func() {
+		db.Exec("DELETE FROM records WHERE slot_id = ?", slot.ID)
+		db.Unscoped().Delete(&models.ActivitySlot{}, slot.ID)
+		db.Unscoped().Delete(&models.Activity{}, activity.ID)
+	})
+	return slot
+}
+
+// bookConcurrently запускает attempts бронирований одновременно, каждое в своей транзакции.
+// Возвращает число успешных и отклонённых из-за нехватки мест; любая другая ошибка валит тест
+func bookConcurrently(t *testing.T, db *gorm.DB, attempts int, book func(tx *gorm.DB, i int) error) (succeeded, full int) {
+	t.Helper()
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	ready := make(chan struct{})
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-ready
+
+			tx := db.Begin()
+			err := book(tx, i)
+			if err != nil {
+				tx.Rollback()
+			} else if err = tx.Commit().Error; err != nil {
+				t.Errorf("failed to commit booking %d: %v", i, err)
+				return
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				succeeded++
+			case errors.Is(err, errSlotFull):
+				full++
+			default:
+				t.Errorf("unexpected booking error %d: %v", i, err)
+			}
+		}(i)
+	}
+	close(ready)
+	wg.Wait()
+	return succeeded, full
+}
+
+// assertSlotBooked перечитывает слот и сверяет счётчик booked
+func assertSlotBooked(t *testing.T, db *gorm.DB, slotID uint, want int) {
+	t.Helper()
+	var stored models.ActivitySlot
+	if err := db.First(&stored, slotID).Error; err != nil {
+		t.Fatalf("failed to reload slot: %v", err)
+	}
+	if stored.Booked > stored.Capacity {
+		t.Errorf("slot overbooked: booked %d, capacity %d", stored.Booked, stored.Capacity)
+	}
+	if stored.Booked != want {
+		t.Errorf("booked counter = %d, want %d", stored.Booked, want)
+	}
+}
+
+func TestReserveSeatsConcurrent(t *testing.T) {
+	db := testDB(t)
+
+	const capacity = 3
+	const attempts = capacity * 4
+	slot := newTestSlot(t, db, capacity)
+
+	succeeded, full := bookConcurrently(t, db, attempts, func(tx *gorm.DB, i int) error {
+		_, err := reserveSeats(tx, slot.ID, 1)
+		return err
+	})
+
+	if succeeded != capacity || full != attempts-capacity {
+		t.Errorf("expected %d reservations and %d rejected, got %d and %d", capacity, attempts-capacity, succeeded, full)
+	}
+	assertSlotBooked(t, db, slot.ID, capacity)
+}
+
+func TestCreateSlotRecordConcurrent(t *testing.T) {
+	db := testDB(t)
+
+	const capacity = 5
+	const attempts = capacity * 4
+	slot := newTestSlot(t, db, capacity)
+
+	succeeded, full := bookConcurrently(t, db, attempts, func(tx *gorm.DB, i int) error {
+		_, _, err := createSlotRecord(tx, slotBooking{
+			PhoneNumber: fmt.Sprintf("+38050%07d", i),
+			ParentName:  "Test",
+			ActivityID:  slot.ActivityID,
+			SlotID:      slot.ID,
+			Kids:        []models.Kid{{Name: fmt.Sprintf("Kid %d", i), Age: 7, Gender: "male"}},
+		})
+		return err
+	})
+
+	if succeeded != capacity || full != attempts-capacity {
+		t.Errorf("expected %d bookings and %d rejected as full, got %d and %d", capacity, attempts-capacity, succeeded, full)
+	}
+	assertSlotBooked(t, db, slot.ID, succeeded)
+
+	var records int64
+	if err := db.Model(&models.Record{}).Where("slot_id = ?", slot.ID).Count(&records).Error; err != nil {
+		t.Fatalf("failed to count records: %v", err)
+	}
+	if records != int64(succeeded) {
+		t.Errorf("created %d records for %d successful bookings", records, succeeded)
+	}
+}
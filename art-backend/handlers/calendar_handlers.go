@@ -0,0 +1,312 @@
+package handlers
+
+import (
+	"art/database"
+	"art/ical"
+	"art/models"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+// Прошедшие занятия остаются в ленте ещё неделю, чтобы не исчезали из календаря сразу после конца
+const calendarPastWindow = 7 * 24 * time.Hour
+
+func newCalendarToken() (string, error) {
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("failed to generate calendar token: %w", err)
+	}
+	return hex.EncodeToString(secret), nil
+}
+
+// calendarFeedURL собирает ссылку, которую клиент вставляет в Google или Apple Calendar
+func calendarFeedURL(c *gin.Context, token string) string {
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	if proto := c.GetHeader("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+	return fmt.Sprintf("%s://%s/calendar/%s.ics", scheme, c.Request.Host, token)
+}
+
+// Событие меняет SEQUENCE при каждом обновлении строки, календари по нему понимают, что версия новее
+func calendarSequence(createdAt, updatedAt time.Time) int {
+	return max(int(updatedAt.Sub(createdAt)/time.Second), 0)
+}
+
+func writeCalendar(c *gin.Context, cal ical.Calendar) {
+	c.Header("Content-Type", "text/calendar; charset=utf-8")
+	c.Header("Cache-Control", "private, max-age=900")
+	c.Status(http.StatusOK)
+	if err := cal.Write(c.Writer); err != nil {
+		log.Error().Err(err).Str("calendar", cal.Name).Msg("Failed to write calendar")
+	}
+}
+
+// Ссылка на личную ленту записей. Токен создаётся при первом запросе
+func GetMyCalendarFeed() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var user models.User
+		db := database.GetGormDB()
+
+		phoneNumber, _ := c.Get("phone_number")
+		if err := db.Where("phone_number = ?", phoneNumber).First(&user).Error; err != nil {
+			log.Error().Err(err).Msgf("Error finding user with phone number: %v", phoneNumber)
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+
+		if user.CalendarToken == nil {
+			token, err := newCalendarToken()
+			if err != nil {
+				log.Error().Err(err).Msg("Failed to create calendar token")
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create calendar link"})
+				return
+			}
+			// Условие на NULL не даёт параллельному запросу перезаписать уже выданный токен
+			res := db.Model(&user).Where("calendar_token IS NULL").Update("calendar_token", token)
+			if res.Error != nil {
+				log.Error().Err(res.Error).Uint("user_id", user.ID).Msg("Failed to save calendar token")
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create calendar link"})
+				return
+			}
+			if res.RowsAffected == 0 {
+				if err := db.First(&user, user.ID).Error; err != nil {
+					log.Error().Err(err).Uint("user_id", user.ID).Msg("Failed to reload user")
+					c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create calendar link"})
+					return
+				}
+			} else {
+				user.CalendarToken = &token
+			}
+		}
+
+		c.JSON(http.StatusOK, gin.H{"url": calendarFeedURL(c, *user.CalendarToken)})
+	}
+}
+
+// Новая ссылка на ленту, старая перестаёт работать. Нужна, если ссылкой поделились по ошибке
+func ResetMyCalendarFeed() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var user models.User
+		db := database.GetGormDB()
+
+		phoneNumber, _ := c.Get("phone_number")
+		if err := db.Where("phone_number = ?", phoneNumber).First(&user).Error; err != nil {
+			log.Error().Err(err).Msgf("Error finding user with phone number: %v", phoneNumber)
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+
+		token, err := newCalendarToken()
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to create calendar token")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create calendar link"})
+			return
+		}
+		if err := db.Model(&user).Update("calendar_token", token).Error; err != nil {
+			log.Error().Err(err).Uint("user_id", user.ID).Msg("Failed to save calendar token")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create calendar link"})
+			return
+		}
+
+		log.Info().Uint("user_id", user.ID).Msg("Calendar token reset")
+		c.JSON(http.StatusOK, gin.H{"url": calendarFeedURL(c, token)})
+	}
+}
+
+func recordEventStatus(record models.Record) string {
+	switch {
+	case record.DeletedAt.Valid, models.IsCancelledRecordStatus(record.Status):
+		return ical.StatusCancelled
+	case record.Status == models.RecordStatusPending:
+		return ical.StatusTentative
+	default:
+		return ical.StatusConfirmed
+	}
+}
+
+// recordEvent — событие записи клиента. UID зависит только от записи, поэтому перенос слота
+// обновляет уже добавленное в календарь событие, а не создаёт новое
+func recordEvent(record models.Record, slot *models.ActivitySlot, location string) ical.Event {
+	record.FillDetailKids()
+	kids := make([]string, len(record.Details.Kids))
+	for i, kid := range record.Details.Kids {
+		kids[i] = kid.Name
+	}
+
+	event := ical.Event{
+		UID:          fmt.Sprintf("record-%d@art-studio", record.ID),
+		Summary:      record.Details.ActivityName,
+		Description:  "Діти: " + strings.Join(kids, ", "),
+		Location:     location,
+		Start:        record.Details.Date,
+		Status:       recordEventStatus(record),
+		LastModified: record.UpdatedAt,
+	}
+	// Время берётся из слота: если его перенесли, событие записи тоже обновится
+	if slot != nil {
+		event.Start = slot.StartTime
+		event.End = slot.EndTime
+		if slot.UpdatedAt.After(event.LastModified) {
+			event.LastModified = slot.UpdatedAt
+		}
+		if slot.DeletedAt.Valid {
+			event.Status = ical.StatusCancelled
+		}
+	}
+	event.Sequence = calendarSequence(record.CreatedAt, event.LastModified)
+	return event
+}
+
+// slotEvent — событие публичного расписания занятия. Удалённый слот отдаётся как CANCELLED
+func slotEvent(activity models.Activity, slot models.ActivitySlot, location string) ical.Event {
+	status := ical.StatusConfirmed
+	if slot.DeletedAt.Valid {
+		status = ical.StatusCancelled
+	}
+	return ical.Event{
+		UID:          fmt.Sprintf("slot-%d@art-studio", slot.ID),
+		Summary:      activity.Name,
+		Description:  activity.Description,
+		Location:     location,
+		Start:        slot.StartTime,
+		End:          slot.EndTime,
+		Status:       status,
+		LastModified: slot.UpdatedAt,
+		Sequence:     calendarSequence(slot.CreatedAt, slot.UpdatedAt),
+	}
+}
+
+// Лента записей клиента по токену из ссылки, без авторизации.
+// Отменённые и удалённые записи остаются в ленте со статусом CANCELLED, чтобы календарь их убрал
+func ClientCalendarFeed() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var user models.User
+		db := database.GetGormDB()
+
+		token := strings.TrimSuffix(c.Param("token"), ".ics")
+		if token == "" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Calendar not found"})
+			return
+		}
+		if err := db.Where("calendar_token = ?", token).First(&user).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Calendar not found"})
+				return
+			}
+			log.Error().Err(err).Msg("Failed to find calendar owner")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load calendar"})
+			return
+		}
+
+		var records []models.Record
+		if err := db.Unscoped().
+			Preload("Kids").
+			Where("user_id = ? AND (details->>'date')::timestamptz >= ?", user.ID, time.Now().Add(-calendarPastWindow)).
+			Order("(details->>'date')::timestamptz ASC").
+			Find(&records).Error; err != nil {
+			log.Error().Err(err).Uint("user_id", user.ID).Msg("Failed to load records for calendar")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load calendar"})
+			return
+		}
+
+		slotIDs := make([]uint, len(records))
+		for i, record := range records {
+			slotIDs[i] = record.SlotID
+		}
+		var slots []models.ActivitySlot
+		if len(slotIDs) > 0 {
+			if err := db.Unscoped().Where("id IN ?", slotIDs).Find(&slots).Error; err != nil {
+				log.Error().Err(err).Uint("user_id", user.ID).Msg("Failed to load slots for calendar")
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load calendar"})
+				return
+			}
+		}
+		slotsByID := make(map[uint]models.ActivitySlot, len(slots))
+		for _, slot := range slots {
+			slotsByID[slot.ID] = slot
+		}
+
+		settings, err := loadStudioSettings(db)
+		if err != nil {
+			log.Error().Err(err).Msg("Error finding studio settings")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load calendar"})
+			return
+		}
+
+		cal := ical.Calendar{Name: "Мої заняття"}
+		if settings.StudioName != "" {
+			cal.Name += " — " + settings.StudioName
+		}
+		for _, record := range records {
+			var slot *models.ActivitySlot
+			if s, ok := slotsByID[record.SlotID]; ok {
+				slot = &s
+			}
+			cal.Events = append(cal.Events, recordEvent(record, slot, settings.StudioAddress))
+		}
+
+		writeCalendar(c, cal)
+	}
+}
+
+// Публичное расписание занятия: будущие слоты. Удалённые слоты отдаются как CANCELLED
+func ActivityCalendarFeed() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var activity models.Activity
+		db := database.GetGormDB()
+
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+			return
+		}
+
+		if err := db.First(&activity, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Activity not found"})
+				return
+			}
+			log.Error().Err(err).Int("id", id).Msg("Failed to find activity for calendar")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load calendar"})
+			return
+		}
+
+		var slots []models.ActivitySlot
+		if err := db.Unscoped().
+			Where("activity_id = ? AND start_time >= ?", activity.ID, time.Now().Add(-calendarPastWindow)).
+			Order("start_time ASC").
+			Find(&slots).Error; err != nil {
+			log.Error().Err(err).Int("id", id).Msg("Failed to load slots for calendar")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load calendar"})
+			return
+		}
+
+		settings, err := loadStudioSettings(db)
+		if err != nil {
+			log.Error().Err(err).Msg("Error finding studio settings")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load calendar"})
+			return
+		}
+
+		cal := ical.Calendar{Name: activity.Name}
+		for _, slot := range slots {
+			cal.Events = append(cal.Events, slotEvent(activity, slot, settings.StudioAddress))
+		}
+
+		writeCalendar(c, cal)
+	}
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"art/ical"
+	"art/models"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func TestCalendarEventUIDStableAcrossSlotUpdates(t *testing.T) {
+	created := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	start := time.Date(2026, 3, 14, 10, 0, 0, 0, time.UTC)
+	activity := models.Activity{Name: "Малювання"}
+
+	slot := models.ActivitySlot{
+		Model:     gorm.Model{ID: 42, CreatedAt: created, UpdatedAt: created},
+		StartTime: start,
+		EndTime:   start.Add(time.Hour),
+	}
+	record := models.Record{
+		Model:   gorm.Model{ID: 7, CreatedAt: created, UpdatedAt: created},
+		SlotID:  slot.ID,
+		Details: models.RecordDetail{ActivityName: activity.Name, Date: start},
+		Status:  models.RecordStatusConfirmed,
+		Kids:    []models.RecordKid{{Name: "Оля"}},
+	}
+
+	// Слот перенесли на час позже, затем удалили
+	moved := slot
+	moved.StartTime = start.Add(time.Hour)
+	moved.EndTime = start.Add(2 * time.Hour)
+	moved.UpdatedAt = created.Add(time.Hour)
+	deleted := moved
+	deleted.UpdatedAt = created.Add(2 * time.Hour)
+	deleted.DeletedAt = gorm.DeletedAt{Time: deleted.UpdatedAt, Valid: true}
+
+	tests := []struct {
+		name   string
+		before ical.Event
+		after  ical.Event
+		status string
+	}{
+		{
+			name:   "slot moved",
+			before: slotEvent(activity, slot, ""),
+			after:  slotEvent(activity, moved, ""),
+			status: ical.StatusConfirmed,
+		},
+		{
+			name:   "slot deleted",
+			before: slotEvent(activity, slot, ""),
+			after:  slotEvent(activity, deleted, ""),
+			status: ical.StatusCancelled,
+		},
+		{
+			name:   "record slot moved",
+			before: recordEvent(record, &slot, ""),
+			after:  recordEvent(record, &moved, ""),
+			status: ical.StatusConfirmed,
+		},
+		{
+			name:   "record slot deleted",
+			before: recordEvent(record, &slot, ""),
+			after:  recordEvent(record, &deleted, ""),
+			status: ical.StatusCancelled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.after.UID != tt.before.UID {
+				t.Errorf("UID changed from %q to %q", tt.before.UID, tt.after.UID)
+			}
+			if tt.after.Sequence <= tt.before.Sequence {
+				t.Errorf("sequence %d did not grow past %d", tt.after.Sequence, tt.before.Sequence)
+			}
+			if !tt.after.Start.Equal(moved.StartTime) {
+				t.Errorf("start = %s, want %s", tt.after.Start, moved.StartTime)
+			}
+			if tt.after.Status != tt.status {
+				t.Errorf("status = %s, want %s", tt.after.Status, tt.status)
+			}
+		})
+	}
+
+	if slotUID, recordUID := slotEvent(activity, slot, "").UID, recordEvent(record, &slot, "").UID; slotUID == recordUID {
+		t.Errorf("slot and record events share UID %q", slotUID)
+	}
+}
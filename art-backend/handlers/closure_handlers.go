@@ -0,0 +1,364 @@
+package handlers
+
+import (
+	"art/database"
+	"art/holidays"
+	"art/models"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+// Слот, который попал в закрытие и уже создан. Владелец решает, удалить его или оставить
+type closureConflict struct {
+	SlotID       uint      `json:"slot_id"`
+	ActivityID   uint      `json:"activity_id"`
+	ActivityName string    `json:"activity_name"`
+	StartTime    time.Time `json:"start_time"`
+	Booked       int       `json:"booked"`
+}
+
+func loadClosures(db *gorm.DB) ([]models.Closure, error) {
+	var closures []models.Closure
+	if err := db.Find(&closures).Error; err != nil {
+		return nil, fmt.Errorf("failed to load closures: %w", err)
+	}
+	return closures, nil
+}
+
+// closedOn находит закрытие, которое выпадает на день занятия
+func closedOn(closures []models.Closure, day time.Time, activityID uint) (models.Closure, bool) {
+	for _, closure := range closures {
+		if closure.Covers(day, activityID) {
+			return closure, true
+		}
+	}
+	return models.Closure{}, false
+}
+
+// closureConflicts ищет будущие слоты, которые попадают в закрытия. Прошедшие занятия не считаются
+func closureConflicts(db *gorm.DB, closures ...models.Closure) ([]closureConflict, error) {
+	conflicts := []closureConflict{}
+	if len(closures) == 0 {
+		return conflicts, nil
+	}
+
+	var slots []closureConflict
+	if err := db.Table("activity_slots s").
+		Select("s.id AS slot_id, s.activity_id, a.name AS activity_name, s.start_time, s.booked").
+		Joins("JOIN activities a ON a.id = s.activity_id").
+		Where("s.deleted_at IS NULL AND s.start_time >= ?", time.Now().UTC()).
+		Order("s.start_time ASC, s.id ASC").
+		Scan(&slots).Error; err != nil {
+		return nil, fmt.Errorf("failed to load slots for closure conflicts: %w", err)
+	}
+
+	for _, slot := range slots {
+		if _, ok := closedOn(closures, slot.StartTime, slot.ActivityID); ok {
+			conflicts = append(conflicts, slot)
+		}
+	}
+	return conflicts, nil
+}
+
+// applyClosureInput разбирает даты и проверяет ввод, на ошибку сам отвечает 400
+func applyClosureInput(c *gin.Context, db *gorm.DB, closure *models.Closure, input models.ClosureInput) bool {
+	start, err := time.Parse("2006-01-02", input.StartDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "start_date must be YYYY-MM-DD"})
+		return false
+	}
+	end := start
+	if input.EndDate != "" {
+		if end, err = time.Parse("2006-01-02", input.EndDate); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "end_date must be YYYY-MM-DD"})
+			return false
+		}
+	}
+	if end.Before(start) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "end_date must not be before start_date"})
+		return false
+	}
+	if input.Recurring && !end.Before(start.AddDate(1, 0, 0)) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Recurring closure must be shorter than a year"})
+		return false
+	}
+
+	if input.ActivityID != nil {
+		if err := db.First(&models.Activity{}, *input.ActivityID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Activity not found"})
+				return false
+			}
+			log.Error().Err(err).Uint("activity_id", *input.ActivityID).Msg("Failed to find activity for closure")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save closure"})
+			return false
+		}
+	}
+
+	closure.Title = input.Title
+	closure.StartDate = start
+	closure.EndDate = end
+	closure.ActivityID = input.ActivityID
+	closure.Recurring = input.Recurring
+	return true
+}
+
+func GetClosures() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var closures []models.Closure
+		db := database.GetGormDB()
+
+		query := db.Order("start_date ASC, id ASC")
+		if value := c.Query("activity_id"); value != "" {
+			activityID, err := strconv.Atoi(value)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid activity_id"})
+				return
+			}
+			// Закрытия всей студии касаются и этого занятия
+			query = query.Where("activity_id = ? OR activity_id IS NULL", activityID)
+		}
+
+		if err := query.Find(&closures).Error; err != nil {
+			log.Error().Err(err).Msg("Failed to fetch closures")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch closures"})
+			return
+		}
+
+		c.JSON(http.StatusOK, closures)
+	}
+}
+
+// Новое закрытие. Уже созданные слоты не удаляются, они возвращаются в conflicts
+func CreateClosure() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input models.ClosureInput
+		db := database.GetGormDB()
+
+		if err := c.ShouldBindJSON(&input); err != nil {
+			log.Error().Err(err).Msg("Error binding JSON")
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		closure := models.Closure{Source: models.ClosureSourceManual}
+		if !applyClosureInput(c, db, &closure, input) {
+			return
+		}
+
+		if err := db.Create(&closure).Error; err != nil {
+			log.Error().Err(err).Msg("Failed to create closure")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create closure"})
+			return
+		}
+
+		conflicts, err := closureConflicts(db, closure)
+		if err != nil {
+			log.Error().Err(err).Uint("closure_id", closure.ID).Msg("Failed to find closure conflicts")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Closure created but failed to check conflicts"})
+			return
+		}
+
+		log.Info().Uint("id", closure.ID).Int("conflicts", len(conflicts)).Msg("Closure created")
+		c.JSON(http.StatusCreated, gin.H{"closure": closure, "conflicts": conflicts})
+	}
+}
+
+func UpdateClosure() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input models.ClosureInput
+		var closure models.Closure
+		db := database.GetGormDB()
+
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+			return
+		}
+
+		if err := c.ShouldBindJSON(&input); err != nil {
+			log.Error().Err(err).Msg("Error binding JSON")
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if err := db.First(&closure, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Closure not found"})
+				return
+			}
+			log.Error().Err(err).Int("id", id).Msg("Failed to find closure")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update closure"})
+			return
+		}
+
+		if !applyClosureInput(c, db, &closure, input) {
+			return
+		}
+
+		if err := db.Save(&closure).Error; err != nil {
+			log.Error().Err(err).Int("id", id).Msg("Failed to update closure")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update closure"})
+			return
+		}
+
+		conflicts, err := closureConflicts(db, closure)
+		if err != nil {
+			log.Error().Err(err).Uint("closure_id", closure.ID).Msg("Failed to find closure conflicts")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Closure updated but failed to check conflicts"})
+			return
+		}
+
+		log.Info().Uint("id", closure.ID).Msg("Closure updated")
+		c.JSON(http.StatusOK, gin.H{"closure": closure, "conflicts": conflicts})
+	}
+}
+
+// Удаление закрытия. Пропущенные слоты появятся при следующей генерации расписания
+func DeleteClosure() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		db := database.GetGormDB()
+
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+			return
+		}
+
+		res := db.Delete(&models.Closure{}, id)
+		if res.Error != nil {
+			log.Error().Err(res.Error).Int("id", id).Msg("Failed to delete closure")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete closure"})
+			return
+		}
+		if res.RowsAffected == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Closure not found"})
+			return
+		}
+
+		log.Info().Int("id", id).Msg("Closure deleted")
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// Уже созданные будущие слоты, которые попадают в закрытие
+func GetClosureConflicts() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var closure models.Closure
+		db := database.GetGormDB()
+
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+			return
+		}
+
+		if err := db.First(&closure, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Closure not found"})
+				return
+			}
+			log.Error().Err(err).Int("id", id).Msg("Failed to find closure")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check conflicts"})
+			return
+		}
+
+		conflicts, err := closureConflicts(db, closure)
+		if err != nil {
+			log.Error().Err(err).Int("id", id).Msg("Failed to find closure conflicts")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check conflicts"})
+			return
+		}
+
+		c.JSON(http.StatusOK, conflicts)
+	}
+}
+
+// Импорт государственных праздников Украины за год (?year=, по умолчанию текущий).
+// Праздники с постоянной датой становятся ежегодными закрытиями, Пасха и Троица — закрытиями на дату года.
+// Повторный импорт уже добавленные праздники пропускает
+func ImportHolidays() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		db := database.GetGormDB()
+
+		year := time.Now().UTC().Year()
+		if value := c.Query("year"); value != "" {
+			var err error
+			if year, err = strconv.Atoi(value); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid year"})
+				return
+			}
+		}
+
+		list, err := holidays.UA(year)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		tx := db.Begin()
+
+		created := []models.Closure{}
+		for _, holiday := range list {
+			query := tx.Model(&models.Closure{}).
+				Where("source = ? AND title = ? AND activity_id IS NULL", models.ClosureSourceHoliday, holiday.Name)
+			if holiday.Fixed {
+				query = query.Where("recurring = ?", true)
+			} else {
+				query = query.Where("recurring = ? AND start_date = ?", false, holiday.Date)
+			}
+			var exists int64
+			if err := query.Count(&exists).Error; err != nil {
+				tx.Rollback()
+				log.Error().Err(err).Msg("Failed to check imported holidays")
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import holidays"})
+				return
+			}
+			if exists > 0 {
+				continue
+			}
+
+			closure := models.Closure{
+				Title:     holiday.Name,
+				StartDate: holiday.Date,
+				EndDate:   holiday.Date,
+				Recurring: holiday.Fixed,
+				Source:    models.ClosureSourceHoliday,
+			}
+			if err := tx.Create(&closure).Error; err != nil {
+				tx.Rollback()
+				log.Error().Err(err).Str("holiday", holiday.Name).Msg("Failed to create holiday closure")
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import holidays"})
+				return
+			}
+			created = append(created, closure)
+		}
+
+		if err := tx.Commit().Error; err != nil {
+			log.Error().Err(err).Msg("Commit failed for import holidays")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import holidays"})
+			return
+		}
+
+		conflicts, err := closureConflicts(db, created...)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to find holiday conflicts")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Holidays imported but failed to check conflicts"})
+			return
+		}
+
+		log.Info().Int("year", year).Int("created", len(created)).Msg("Holidays imported")
+		c.JSON(http.StatusCreated, gin.H{
+			"created":   created,
+			"skipped":   len(list) - len(created),
+			"conflicts": conflicts,
+		})
+	}
+}
@@ -47,6 +47,14 @@ func GetActivities() gin.HandlerFunc {
 
 		regularParam := strings.ToLower(c.Query("regular"))
 
+		ageParam := c.Query("age") // Занятия, подходящие ребёнку этого возраста
+		if ageParam != "" {
+			if age, err := strconv.Atoi(ageParam); err != nil || age < 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid age"})
+				return
+			}
+		}
+
 		redisClient, err := database.GetRedis()
 		if err != nil {
 			log.Error().Err(err).Msg("Error getting redis")
@@ -54,7 +62,7 @@ func GetActivities() gin.HandlerFunc {
 			return
 		}
 
-		cacheKey := fmt.Sprintf("activities:page=%d:size=%d:filter=%s", page, size, regularParam)
+		cacheKey := fmt.Sprintf("activities:page=%d:size=%d:filter=%s:age=%s", page, size, regularParam, ageParam)
 
 		var resp gin.H
 
@@ -78,6 +86,10 @@ func GetActivities() gin.HandlerFunc {
 			// если параметр не передан — отдаём ВСЁ (как сейчас делает фронт)
 		}
 
+		if ageParam != "" {
+			query = query.Where("(min_age IS NULL OR min_age <= ?) AND (max_age IS NULL OR max_age >= ?)", ageParam, ageParam)
+		}
+
 		var totalCount int64
 		if err := query.Count(&totalCount).Error; err != nil {
 			log.Error().Err(err).Msg("Failed to count activities")
@@ -187,6 +199,16 @@ func AddActivity() gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Duration must be greater than 0"})
 			return
 		}
+		if !req.AgeRange.Valid() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "min_age must not be greater than max_age"})
+			return
+		}
+		if req.WorkingHours != nil {
+			if err := validateWorkingHours(*req.WorkingHours); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
 
 		var act_image models.ActivityImage
 
@@ -195,12 +217,14 @@ func AddActivity() gin.HandlerFunc {
 		act_image.Caption = req.Images.Caption
 
 		activity := models.Activity{
-			Name:        req.Name,
-			Description: req.Description,
-			Images:      act_image,
-			Price:       req.Price,
-			Duration:    req.Duration,
-			IsRegular:   req.IsRegular,
+			Name:         req.Name,
+			Description:  req.Description,
+			Images:       act_image,
+			Price:        req.Price,
+			Duration:     req.Duration,
+			IsRegular:    req.IsRegular,
+			AgeRange:     req.AgeRange,
+			WorkingHours: req.WorkingHours,
 		}
 
 		if act_image.Photo == nil {
@@ -269,12 +293,19 @@ func UpdateActivity() gin.HandlerFunc {
 			return
 		}
 
-		if err := c.ShouldBindJSON(&updated_act); err != nil {
+		fields, err := bindJSONFields(c, &updated_act)
+		if err != nil {
 			log.Error().Err(err).Msg("Failed to bind json")
 			c.JSON(http.StatusBadRequest, gin.H{
 				"Invalid of input data": err.Error()})
 			return
 		}
+		if updated_act.WorkingHours != nil {
+			if err := validateWorkingHours(*updated_act.WorkingHours); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
 
 		if err := db.First(&act, id).Error; err != nil {
 			if !errors.Is(err, gorm.ErrRecordNotFound) {
@@ -293,6 +324,14 @@ func UpdateActivity() gin.HandlerFunc {
 		act.Price = updated_act.Price
 		act.Duration = updated_act.Duration
 		act.IsRegular = updated_act.IsRegular
+		mergeAgeRange(&act.AgeRange, updated_act.AgeRange, fields)
+		if !act.AgeRange.Valid() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "min_age must not be greater than max_age"})
+			return
+		}
+		if hasJSONField(fields, "working_hours") { // null — снова часы студии
+			act.WorkingHours = updated_act.WorkingHours
+		}
 
 		tx := db.Begin()
 		defer func() {
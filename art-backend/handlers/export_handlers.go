@@ -0,0 +1,250 @@
+package handlers
+
+import (
+	"art/database"
+	"art/export"
+	"art/models"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+// Выгрузка читается из базы пачками, в памяти одновременно не больше exportBatchSize строк
+const exportBatchSize = 500
+
+// Общий WriteTimeout сервера рассчитан на обычные ответы, большой выгрузке нужно больше времени
+const exportWriteTimeout = 10 * time.Minute
+
+// startExport проверяет формат и отдаёт заголовки файла. После первой строки статус ответа
+// уже не поменять, поэтому ошибки в середине выгрузки только логируются
+func startExport(c *gin.Context, name, sheet string) (export.Writer, bool) {
+	format := c.DefaultQuery("format", export.FormatCSV)
+	if format != export.FormatCSV && format != export.FormatXLSX {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be csv or xlsx"})
+		return nil, false
+	}
+
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Now().Add(exportWriteTimeout)); err != nil {
+		log.Warn().Err(err).Str("export", name).Msg("Failed to extend write deadline for export")
+	}
+
+	filename := fmt.Sprintf("%s-%s.%s", name, time.Now().Format("2006-01-02"), format)
+	c.Header("Content-Type", export.ContentType(format))
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Status(http.StatusOK)
+
+	w, err := export.NewWriter(format, c.Writer, sheet)
+	if err != nil {
+		log.Error().Err(err).Str("export", name).Msg("Failed to start export")
+		return nil, false
+	}
+	return w, true
+}
+
+// exportKeyset — порядок выгрузки. Страницы выбираются по ключу (Column, id) последней строки, а не смещением,
+// поэтому строки, добавленные или удалённые во время выгрузки, не сдвигают следующие страницы
+type exportKeyset[T any] struct {
+	Column string // SQL-выражение сортировки, пустое — только по id
+	Desc   bool
+	Key    func(T) (any, uint) // Значение Column и id строки
+}
+
+func (k exportKeyset[T]) order() string {
+	direction := "ASC"
+	if k.Desc {
+		direction = "DESC"
+	}
+	if k.Column == "" {
+		return "id " + direction
+	}
+	return fmt.Sprintf("%s %s, id %s", k.Column, direction, direction)
+}
+
+// after ограничивает запрос строками, идущими в порядке выгрузки после last
+func (k exportKeyset[T]) after(query *gorm.DB, last T) *gorm.DB {
+	op := ">"
+	if k.Desc {
+		op = "<"
+	}
+	value, id := k.Key(last)
+	if k.Column == "" {
+		return query.Where("id "+op+" ?", id)
+	}
+	return query.Where(fmt.Sprintf("(%s, id) %s (?, ?)", k.Column, op), value, id)
+}
+
+// exportInBatches выбирает строки запроса страницами в порядке keyset и отдаёт каждую в write
+func exportInBatches[T any](c *gin.Context, w export.Writer, query *gorm.DB, keyset exportKeyset[T], write func(T) error) error {
+	query = query.Order(keyset.order()).Session(&gorm.Session{})
+	page := query
+	for {
+		var batch []T
+		if err := page.Limit(exportBatchSize).Find(&batch).Error; err != nil {
+			return fmt.Errorf("failed to load export batch: %w", err)
+		}
+		for _, item := range batch {
+			if err := write(item); err != nil {
+				return err
+			}
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		c.Writer.Flush()
+
+		if len(batch) < exportBatchSize {
+			return nil
+		}
+		page = keyset.after(query, batch[len(batch)-1])
+	}
+}
+
+func finishExport(w export.Writer, name string, err error) {
+	if err == nil {
+		err = w.Close()
+	}
+	if err != nil {
+		log.Error().Err(err).Str("export", name).Msg("Export interrupted")
+		return
+	}
+	log.Info().Str("export", name).Msg("Export finished")
+}
+
+func kidsSummary[T any](kids []T, describe func(T) string) string {
+	parts := make([]string, len(kids))
+	for i, kid := range kids {
+		parts[i] = describe(kid)
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Выгрузка записей для бухгалтерии. Понимает те же фильтры и сортировку, что и GET /records
+func ExportRecords() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		db := database.GetGormDB()
+
+		filter, ok := parseRecordFilter(c)
+		if !ok {
+			return
+		}
+
+		w, ok := startExport(c, "records", "Записи")
+		if !ok {
+			return
+		}
+
+		err := w.WriteRow("ID", "Дата заняття", "Заняття", "Статус", "Телефон", "Батьки",
+			"Діти", "Кількість дітей", "Ціна", "Абонемент", "Створено")
+		if err == nil {
+			query := filter.apply(db.Model(&models.Record{})).Preload("Kids")
+			keyset := exportKeyset[models.Record]{
+				Column: filter.sortColumn(),
+				Desc:   filter.Desc,
+				Key: func(record models.Record) (any, uint) {
+					if filter.Sort == recordSortDate {
+						return record.Details.Date, record.ID
+					}
+					return record.CreatedAt, record.ID
+				},
+			}
+			err = exportInBatches(c, w, query, keyset, func(record models.Record) error {
+				record.FillDetailKids()
+				return w.WriteRow(
+					record.ID,
+					record.Details.Date,
+					record.Details.ActivityName,
+					record.Status,
+					record.PhoneNumber,
+					record.ParentName,
+					kidsSummary(record.Details.Kids, func(kid models.Kid) string {
+						return fmt.Sprintf("%s (%d)", kid.Name, kid.Age)
+					}),
+					record.Details.NumberOfKids,
+					record.TotalPrice,
+					record.SubscriptionID != nil,
+					record.CreatedAt,
+				)
+			})
+		}
+		finishExport(w, "records", err)
+	}
+}
+
+func ExportSubscriptions() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		db := database.GetGormDB()
+
+		w, ok := startExport(c, "subscriptions", "Абонементи")
+		if !ok {
+			return
+		}
+
+		err := w.WriteRow("ID", "Клієнт", "Телефон", "Абонемент", "Заняття", "Діти",
+			"Початок", "Кінець", "Використано візитів", "Всього візитів", "Сплачено", "Створено")
+		if err == nil {
+			query := db.Model(&models.Subscription{}).
+				Preload("User").
+				Preload("SubKids").
+				Preload("SubscriptionType").
+				Preload("SubscriptionType.Activity")
+			keyset := exportKeyset[models.Subscription]{Key: func(sub models.Subscription) (any, uint) { return nil, sub.ID }}
+			err = exportInBatches(c, w, query, keyset, func(sub models.Subscription) error {
+				return w.WriteRow(
+					sub.ID,
+					strings.TrimSpace(sub.User.Name+" "+sub.User.Surname),
+					sub.User.PhoneNumber,
+					sub.SubscriptionType.Name,
+					sub.SubscriptionType.Activity.Name,
+					kidsSummary(sub.SubKids, func(kid models.SubKid) string {
+						return fmt.Sprintf("%s (%d)", kid.Name, kid.Age)
+					}),
+					sub.StartDate,
+					sub.EndDate,
+					sub.VisitsUsed,
+					sub.VisitsTotal,
+					sub.PricePaid,
+					sub.CreatedAt,
+				)
+			})
+		}
+		finishExport(w, "subscriptions", err)
+	}
+}
+
+func ExportUsers() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		db := database.GetGormDB()
+
+		w, ok := startExport(c, "users", "Клієнти")
+		if !ok {
+			return
+		}
+
+		err := w.WriteRow("ID", "Логін", "Ім'я", "Прізвище", "Телефон", "Роль", "Гість", "Діти", "Зареєстровано")
+		if err == nil {
+			query := db.Model(&models.User{}).Preload("UserKids")
+			keyset := exportKeyset[models.User]{Key: func(user models.User) (any, uint) { return nil, user.ID }}
+			err = exportInBatches(c, w, query, keyset, func(user models.User) error {
+				return w.WriteRow(
+					user.ID,
+					user.Username,
+					user.Name,
+					user.Surname,
+					user.PhoneNumber,
+					user.Role,
+					user.IsGuest,
+					kidsSummary(user.UserKids, func(kid models.UserKid) string {
+						return fmt.Sprintf("%s (%d)", kid.Name, kid.Age)
+					}),
+					user.CreatedAt,
+				)
+			})
+		}
+		finishExport(w, "users", err)
+	}
+}
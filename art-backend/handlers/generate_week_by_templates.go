@@ -43,7 +43,10 @@ func GetAllTemplates() gin.HandlerFunc {
 			}
 		}
 
-		query := db.Model(&models.ScheduleTemplate{})
+		query, ok := templateFilter(c, db.Model(&models.ScheduleTemplate{}))
+		if !ok {
+			return
+		}
 
 		var totalCount int64
 		if err := query.Count(&totalCount).Error; err != nil {
@@ -110,8 +113,13 @@ func GetTemplatesByActID() gin.HandlerFunc {
 			return
 		}
 
-		if err := db.Where("activity_id = ?", act_id).
-			Order("day_of_week ASC, start_time ASC").
+		query, ok := templateFilter(c, db.Where("activity_id = ?", act_id))
+		if !ok {
+			return
+		}
+
+		if err := query.
+			Order("day_of_week ASC, start_time ASC, valid_from ASC NULLS FIRST").
 			Find(&templates).Error; err != nil {
 			log.Error().Err(err).Msgf("Error finding templates for activity_id: %d", act_id)
 			c.JSON(http.StatusNotFound, gin.H{"error": "Templates not found for this activity"})
@@ -185,13 +193,17 @@ func AddTemplate() gin.HandlerFunc {
 			return
 		}
 
-		if input.DayOfWeek < 1 || input.DayOfWeek > 5 {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "day_of_week must be 1-5 (Mon-Fri)"})
+		if _, err := utils.ParseTemplateTime(input.StartTime); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start_time"})
 			return
 		}
 		if input.Capacity < 1 {
 			input.Capacity = 10
 		}
+		if !input.AgeRange.Valid() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "min_age must not be greater than max_age"})
+			return
+		}
 
 		var act models.Activity
 		if err := db.First(&act, activityID).Error; err != nil {
@@ -203,34 +215,40 @@ func AddTemplate() gin.HandlerFunc {
 			return
 		}
 
+		settings, err := loadStudioSettings(db)
+		if err != nil {
+			log.Error().Err(err).Msg("Error finding studio settings")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load studio settings"})
+			return
+		}
+		if !checkTemplateHours(c, activityWorkingHours(settings, act), act, input.DayOfWeek, input.StartTime) {
+			return
+		}
+
 		template := models.ScheduleTemplate{
 			ActivityID: uint(activityID),
 			DayOfWeek:  input.DayOfWeek,
 			StartTime:  input.StartTime,
 			Capacity:   input.Capacity,
+			AgeRange:   input.AgeRange,
 		}
-
-		var existing models.ScheduleTemplate
-
-		err = db.Where(
-			"activity_id = ? AND day_of_week = ? AND start_time = ?",
-			activityID,
-			input.DayOfWeek,
-			input.StartTime,
-		).First(&existing).Error
-		if err == nil {
-			c.JSON(http.StatusConflict, gin.H{
-				"error": "Шаблон з таким днем та часом вже існує",
-			})
+		if !applyTemplatePeriod(c, &template, input) {
 			return
 		}
-		if !errors.Is(err, gorm.ErrRecordNotFound) {
+
+		// В тот же день и время может быть другой шаблон, если их периоды не пересекаются
+		existing, overlaps, err := templateOverlaps(db, template)
+		if err != nil {
 			// Реальная ошибка БД
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"error": "Помилка перевірки шаблону",
 			})
 			return
 		}
+		if overlaps {
+			respondTemplateOverlap(c, existing)
+			return
+		}
 
 		tx := db.Begin()
 		defer func() {
@@ -241,6 +259,10 @@ func AddTemplate() gin.HandlerFunc {
 
 		if err := tx.Create(&template).Error; err != nil {
 			tx.Rollback()
+			if isExclusionViolation(err) {
+				respondTemplateExclusion(c, db, template)
+				return
+			}
 			if strings.Contains(err.Error(), "duplicate") {
 				c.JSON(http.StatusConflict, gin.H{
 					"error": "Template already exist",
@@ -285,7 +307,8 @@ func UpdateTemplate() gin.HandlerFunc {
 			return
 		}
 
-		if err := c.ShouldBindJSON(&input); err != nil {
+		fields, err := bindJSONFields(c, &input)
+		if err != nil {
 			log.Error().Err(err).Msg("Error binding json")
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
 			return
@@ -305,23 +328,72 @@ func UpdateTemplate() gin.HandlerFunc {
 			input.Capacity = 10
 		}
 
+		mergeAgeRange(&template.AgeRange, input.AgeRange, fields)
+		if !template.AgeRange.Valid() {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": "min_age must not be greater than max_age"})
+			return
+		}
+
 		template.DayOfWeek = input.DayOfWeek
 		template.Capacity = input.Capacity
 
 		if input.StartTime != "" {
 			if _, err := utils.ParseTemplateTime(input.StartTime); err != nil {
+				tx.Rollback()
 				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start_time"})
 				return
 			}
 			template.StartTime = input.StartTime
 		}
 
+		var act models.Activity
+		if err := tx.First(&act, template.ActivityID).Error; err != nil {
+			tx.Rollback()
+			log.Error().Err(err).Uint("activity_id", template.ActivityID).Msg("Error finding activity of template")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to find activity"})
+			return
+		}
+		settings, err := loadStudioSettings(tx)
+		if err != nil {
+			tx.Rollback()
+			log.Error().Err(err).Msg("Error finding studio settings")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load studio settings"})
+			return
+		}
+		if !checkTemplateHours(c, activityWorkingHours(settings, act), act, template.DayOfWeek, template.StartTime) {
+			tx.Rollback()
+			return
+		}
+
+		if !applyTemplatePeriod(c, &template, input) {
+			tx.Rollback()
+			return
+		}
+		existing, overlaps, err := templateOverlaps(tx, template)
+		if err != nil {
+			tx.Rollback()
+			log.Error().Err(err).Msg("Error checking template overlap")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Помилка перевірки шаблону"})
+			return
+		}
+		if overlaps {
+			tx.Rollback()
+			respondTemplateOverlap(c, existing)
+			return
+		}
+
 		if err := tx.Save(&template).Error; err != nil {
 			tx.Rollback()
+			if isExclusionViolation(err) {
+				respondTemplateExclusion(c, db, template)
+				return
+			}
 			log.Error().Err(err).Msg("Failed to save template")
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"Error to save template": err,
 			})
+			return
 		}
 
 		if err := tx.Commit().Error; err != nil {
@@ -401,7 +473,7 @@ func ExtendSchedule() gin.HandlerFunc {
 			weeks = 1
 		}
 
-		errSubs, err := GenerateRegularSlots(weeks)
+		errSubs, _, err := GenerateRegularSlots(weeks)
 		if err != nil {
 			log.Error().Err(err).Msg("Error extending schedule")
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to extend schedule"})
@@ -444,14 +516,35 @@ func ExtendSchedule() gin.HandlerFunc {
 	}
 }
 
-func GenerateRegularSlots(weeks int) ([]models.Subscription, error) {
+// generateStats — сколько слотов создала генерация и сколько новых сообщений о неподходящем возрасте сохранила автозапись
+type generateStats struct {
+	SlotsCreated int
+	AgeErrors    int
+}
+
+// GenerateRegularSlots создаёт слоты по шаблонам на weeks недель вперёд и возвращает
+// не записанные абонементы и статистику генерации
+func GenerateRegularSlots(weeks int) ([]models.Subscription, generateStats, error) {
 	db := database.GetGormDB()
 
 	var AllErrSubs []models.Subscription
+	var stats generateStats
 	var activities []models.Activity
 	if err := db.Where("is_regular = ?", true).Find(&activities).Error; err != nil {
 		log.Error().Err(err).Msg("Error finding activities")
-		return AllErrSubs, err
+		return AllErrSubs, stats, err
+	}
+
+	settings, err := loadStudioSettings(db)
+	if err != nil {
+		log.Error().Err(err).Msg("Error finding studio settings")
+		return AllErrSubs, stats, err
+	}
+
+	closures, err := loadClosures(db)
+	if err != nil {
+		log.Error().Err(err).Msg("Error finding closures")
+		return AllErrSubs, stats, err
 	}
 
 	now := time.Now().UTC()
@@ -460,24 +553,35 @@ func GenerateRegularSlots(weeks int) ([]models.Subscription, error) {
 
 	for _, act := range activities {
 		var templates []models.ScheduleTemplate
-		if err := db.Where("activity_id = ?", act.ID).Find(&templates).Error; err != nil {
+		if err := db.Where("activity_id = ? AND (valid_to IS NULL OR valid_to >= ?)", act.ID, startDate).
+			Find(&templates).Error; err != nil {
 			log.Error().Err(err).Msg("Error finding templates")
-			return AllErrSubs, err
+			return AllErrSubs, stats, err
 		}
 
+		hours := activityWorkingHours(settings, act)
+
 		for current := startDate; current.Before(endDate); current = current.AddDate(0, 0, 1) {
-			if current.Weekday() == time.Saturday || current.Weekday() == time.Sunday {
-				continue // Пропускаем выходные
+			if !hours.IsWorkingDay(isoWeekday(current)) {
+				continue // Выходной студии или занятия
 			}
-
-			jsDay := int(current.Weekday())
-			if jsDay == 0 {
-				jsDay = 7
+			if closure, closed := closedOn(closures, current, act.ID); closed {
+				log.Debug().Uint("activity_id", act.ID).Uint("closure_id", closure.ID).
+					Str("date", current.Format("2006-01-02")).Msg("Skipping closed day")
+				continue // Праздник, каникулы или закрытие занятия
 			}
 
+			jsDay := isoWeekday(current)
+
 			for _, tmpl := range templates {
 
-				if tmpl.DayOfWeek != jsDay {
+				if tmpl.DayOfWeek != jsDay || !tmpl.ValidOn(current) {
+					continue // Другой день или шаблон другого сезона
+				}
+				// Шаблон мог остаться от прежних рабочих часов, слот вне часов не создаём
+				if !hours.Allows(tmpl.DayOfWeek, tmpl.StartTime, act.Duration) {
+					log.Warn().Uint("template_id", tmpl.ID).Uint("activity_id", act.ID).
+						Msg("Template is outside working hours, slot skipped")
 					continue
 				}
 
@@ -510,39 +614,54 @@ func GenerateRegularSlots(weeks int) ([]models.Subscription, error) {
 				if res := tx.Create(&slot); res.Error != nil {
 					tx.Rollback()
 					log.Error().Err(res.Error).Msg("Error to create slot")
-					return AllErrSubs, fmt.Errorf("error: %e", res.Error)
+					return AllErrSubs, stats, fmt.Errorf("error: %e", res.Error)
 				}
 
 				if err := tx.Commit().Error; err != nil {
 					log.Error().Err(err).Msg("Commit failed for creating slot on generate slots func")
-					return AllErrSubs, err
+					return AllErrSubs, stats, err
 				}
+				stats.SlotsCreated++
 
 				log.Info().Msgf("Generated slots for activity %d: %s", act.ID, act.Name)
 
 				// Создание слотов и записи на них по подписке не атомарны, расписание может продлиться без них
 				// Неудавшиеся к продлению подписки можно будет найти на странице ошибок, чтобы записать вручную, если важно
-				errSubs, err := autoEnrollSubscriptions(db, &slot)
+				errSubs, ageErrors, err := autoEnrollSubscriptions(db, &slot)
 				if err != nil {
 					log.Error().Err(err).Msg("Failed to auto-enroll subscriptions") // Логирование без прерывания генерации
 				}
+				stats.AgeErrors += ageErrors
 
 				AllErrSubs = append(AllErrSubs, errSubs...)
 
+				// Серии разовых записей бронируются после абонементов, у которых приоритет на места
+				if err := bookSeriesForSlot(db, &slot); err != nil {
+					log.Error().Err(err).Msg("Failed to book series for slot")
+				}
 			}
 		}
 	}
-	return AllErrSubs, nil
+	return AllErrSubs, stats, nil
 }
 
-func autoEnrollSubscriptions(db *gorm.DB, slot *models.ActivitySlot) ([]models.Subscription, error) {
+// autoEnrollSubscriptions записывает абонементы на слот. Возвращает абонементы, которые записать не удалось,
+// и число новых сообщений о детях, не подходящих по возрасту
+func autoEnrollSubscriptions(db *gorm.DB, slot *models.ActivitySlot) ([]models.Subscription, int, error) {
 
 	log.Info().Msgf("Starting auto-enroll for activity %d, slot %d", slot.ActivityID, slot.ID)
 	var errSubs []models.Subscription
+	ageErrors := 0
+
+	settings, err := loadStudioSettings(db)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to load studio settings")
+		return errSubs, ageErrors, err
+	}
 
 	var subscriptions []models.Subscription // Поиск всех активных абонементов на эту активность
 	// visits_used пока не проверяю, проверю уже дальше в lockedSub
-	err := db.
+	err = db.
 		Joins("JOIN subscription_types st ON st.id = subscriptions.subscription_type_id").
 		Where(`
         st.activity_id = ?
@@ -555,11 +674,20 @@ func autoEnrollSubscriptions(db *gorm.DB, slot *models.ActivitySlot) ([]models.S
 
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to find subscriptions")
-		return errSubs, err
+		return errSubs, ageErrors, err
 	}
 
 	log.Info().Int("subscriptions_count", len(subscriptions)).Msg("Found subscriptions")
 
+	var ages models.AgeRange
+	if len(subscriptions) > 0 {
+		ages, err = slotAgeRange(db, subscriptions[0].SubscriptionType.Activity, *slot)
+		if err != nil {
+			log.Error().Err(err).Uint("slot_id", slot.ID).Msg("Failed to load age limits")
+			return errSubs, ageErrors, err
+		}
+	}
+
 	for _, sub := range subscriptions {
 		log.Info().Uint("sub_id", sub.ID).Msg("Processing subscription")
 
@@ -581,9 +709,30 @@ func autoEnrollSubscriptions(db *gorm.DB, slot *models.ActivitySlot) ([]models.S
 			continue
 		}
 
+		if settings.VisitsByAttendance {
+			// Визиты списываются по факту посещения, поэтому будущие записи тоже занимают остаток абонемента
+			pending, err := pendingSubscriptionVisits(db, lockedSub.ID)
+			if err != nil {
+				log.Warn().Uint("sub_id", sub.ID).Err(err).Msg("Failed to count pending visits")
+				continue
+			}
+			if lockedSub.VisitsUsed+int(pending) >= lockedSub.VisitsTotal {
+				log.Info().Uint("sub_id", lockedSub.ID).Msg("Subscription fully booked by pending records")
+				continue
+			}
+		}
+
 		for _, subKid := range lockedSub.SubKids {
 			log.Info().Uint("sub_id", sub.ID).Str("kid_name", subKid.Name).Msg("Processing kid")
 
+			if !ages.Allows(subKid.Age) {
+				log.Info().Uint("sub_kid_id", subKid.ID).Int("age", subKid.Age).Msg("Kid does not fit activity age limits, skipping")
+				if saveAgeError(lockedSub, subKid, slot, ages) {
+					ageErrors++
+				}
+				continue
+			}
+
 			// Проверка существующих записей
 			can, err := canEnrollKid(db, &lockedSub, &subKid, slot)
 			if err != nil {
@@ -606,14 +755,22 @@ func autoEnrollSubscriptions(db *gorm.DB, slot *models.ActivitySlot) ([]models.S
 				continue
 			}
 
-			if lockedSlot.Booked >= lockedSlot.Capacity {
+			held, err := heldSeats(tx, lockedSlot.ID, 0)
+			if err != nil {
+				errSubs = append(errSubs, lockedSub)
+				tx.Rollback()
+				log.Warn().Uint("slot_id", slot.ID).Err(err).Msg("Failed to count held seats")
+				continue
+			}
+
+			if lockedSlot.Booked+held >= lockedSlot.Capacity {
 				tx.Rollback()
 				log.Info().Uint("slot_id", slot.ID).Msg("Slot is full after lock")
 				continue
 			}
 
 			record := models.Record{
-				UserID:         lockedSub.UserID,
+				UserID:         &lockedSub.UserID,
 				SubKidID:       &subKid.ID,
 				SubscriptionID: &lockedSub.ID,
 				PhoneNumber:    lockedSub.User.PhoneNumber,
@@ -625,12 +782,15 @@ func autoEnrollSubscriptions(db *gorm.DB, slot *models.ActivitySlot) ([]models.S
 					ActivityName: lockedSub.SubscriptionType.Activity.Name,
 					Date:         slot.StartTime,
 					NumberOfKids: 1,
-					Kids: []models.Kid{
-						{
-							Name:   subKid.Name,
-							Age:    subKid.Age,
-							Gender: subKid.Gender,
-						},
+				},
+				VisitCounted: !settings.VisitsByAttendance,
+				Kids: []models.RecordKid{
+					{
+						SlotID:   slot.ID,
+						SubKidID: &subKid.ID,
+						Name:     subKid.Name,
+						Age:      subKid.Age,
+						Gender:   subKid.Gender,
 					},
 				},
 			}
@@ -642,14 +802,16 @@ func autoEnrollSubscriptions(db *gorm.DB, slot *models.ActivitySlot) ([]models.S
 				continue
 			}
 
-			// Увеличиваем счётчик посещений в абонементе
-			if err := tx.Model(&models.Subscription{}).
-				Where("id = ?", lockedSub.ID).
-				UpdateColumn("visits_used", gorm.Expr("visits_used + 1")).Error; err != nil {
-				errSubs = append(errSubs, lockedSub)
-				tx.Rollback()
-				log.Error().Err(err).Msgf("Failed to update subscription visits_used for id %d", sub.ID)
-				continue
+			// Увеличиваем счётчик посещений в абонементе, если визит списывается при записи
+			if record.VisitCounted {
+				if err := tx.Model(&models.Subscription{}).
+					Where("id = ?", lockedSub.ID).
+					UpdateColumn("visits_used", gorm.Expr("visits_used + 1")).Error; err != nil {
+					errSubs = append(errSubs, lockedSub)
+					tx.Rollback()
+					log.Error().Err(err).Msgf("Failed to update subscription visits_used for id %d", sub.ID)
+					continue
+				}
 			}
 
 			// Увеличиваем Booked в слоте
@@ -676,7 +838,7 @@ func autoEnrollSubscriptions(db *gorm.DB, slot *models.ActivitySlot) ([]models.S
 	}
 
 	log.Info().Msgf("Auto-enroll completed for slot %d", slot.ID)
-	return errSubs, nil
+	return errSubs, ageErrors, nil
 }
 
 func EnrollSubs() gin.HandlerFunc {
@@ -701,7 +863,7 @@ func EnrollSubs() gin.HandlerFunc {
 		}
 
 		for _, slot := range slots {
-			errSubs, err := autoEnrollSubscriptions(db, &slot)
+			errSubs, _, err := autoEnrollSubscriptions(db, &slot)
 			if err != nil {
 				log.Error().Err(err).Msgf("Error while auto-enroll subs")
 				return
@@ -756,7 +918,7 @@ func EnrollSubsBySlotID() gin.HandlerFunc {
 			return
 		}
 
-		errSubs, err := autoEnrollSubscriptions(db, &slot)
+		errSubs, _, err := autoEnrollSubscriptions(db, &slot)
 		if err != nil {
 			log.Error().Err(err).Msgf("Error while auto-enroll subs by slot id: %d", slot_id)
 			return
@@ -782,6 +944,7 @@ func EnrollSubsBySlotID() gin.HandlerFunc {
 
 func canEnrollKid(db *gorm.DB, sub *models.Subscription, subKid *models.SubKid, slot *models.ActivitySlot) (bool, error) {
 	var count int64
+	// Отменённые записи тоже учитываются: отменённое клиентом занятие повторно не записываем
 	err := db.Model(&models.Record{}).
 		Where("sub_kid_id = ? AND slot_id = ?", subKid.ID, slot.ID).
 		Count(&count).Error
@@ -799,7 +962,51 @@ func canEnrollKid(db *gorm.DB, sub *models.Subscription, subKid *models.SubKid,
 	return true, nil
 }
 
-func saveSubErrors(subs []models.Subscription) {
+// Записи по абонементу на будущие занятия, за которые визит ещё не списан
+func pendingSubscriptionVisits(db *gorm.DB, subID uint) (int64, error) {
+	var count int64
+	err := db.Model(&models.Record{}).
+		Joins("JOIN activity_slots s ON s.id = records.slot_id").
+		Where("records.subscription_id = ? AND records.visit_counted = false AND s.start_time > ?", subID, time.Now()).
+		Where("records.status IN ?", models.ActiveRecordStatuses).
+		Count(&count).Error
+	return count, err
+}
+
+// saveAgeError сохраняет для владельца причину, по которой ребёнок с абонемента не записан на слот.
+// Автозапись проходит по слотам каждую ночь, поэтому об одном и том же ребёнке на слоте сообщается один раз,
+// в том числе если владелец уже удалил это сообщение. Возвращает true, если ошибка создана
+func saveAgeError(sub models.Subscription, kid models.SubKid, slot *models.ActivitySlot, ages models.AgeRange) bool {
+	studio_error := models.StudioError{
+		SubscriptionId: sub.ID,
+		SlotId:         slot.ID,
+		Info: fmt.Sprintf("Автозапис пропущено: дитина %v (вiк %v) за абонементом id %v не підходить за віком для заняття %v (%s років)",
+			kid.Name, kid.Age, sub.ID, slot.StartTime.Format("02.01.2006 15:04"), ages),
+	}
+
+	db := database.GetGormDB()
+
+	var existing int64
+	if err := db.Unscoped().Model(&models.StudioError{}).
+		Where("subscription_id = ? AND slot_id = ? AND info = ?", studio_error.SubscriptionId, studio_error.SlotId, studio_error.Info).
+		Count(&existing).Error; err != nil {
+		log.Error().Err(err).Msgf("Error checking studio error for sub id: %d", sub.ID)
+		return false
+	}
+	if existing > 0 {
+		return false
+	}
+
+	if err := db.Create(&studio_error).Error; err != nil {
+		log.Error().Err(err).Msgf("Error creating studio error for sub id: %d", sub.ID)
+		return false
+	}
+	return true
+}
+
+// saveSubErrors сохраняет сообщения о пропущенных абонементах и возвращает, сколько сохранено
+func saveSubErrors(subs []models.Subscription) int {
+	saved := 0
 	for _, sub := range subs {
 		var studio_error models.StudioError
 
@@ -809,12 +1016,15 @@ func saveSubErrors(subs []models.Subscription) {
 		db := database.GetGormDB()
 		tx := db.Begin()
 		if err := tx.Create(&studio_error).Error; err != nil {
+			tx.Rollback()
 			log.Error().Err(err).Msgf("Error creating studio error for sub id: %d", sub.ID)
 			continue
 		}
 		if err := tx.Commit().Error; err != nil {
 			log.Error().Err(err).Msg("Commit failed for create studio_error")
-			return
+			return saved
 		}
+		saved++
 	}
+	return saved
 }
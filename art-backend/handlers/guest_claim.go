@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"art/database"
+	"art/models"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+const (
+	guestClaimTTL         = 72 * time.Hour
+	guestClaimMaxAttempts = 5
+)
+
+var (
+	errGuestClaimRequired = errors.New("guest account requires claim code")
+	errGuestClaimInvalid  = errors.New("invalid guest claim code")
+)
+
+func guestClaimKey(userID uint) string {
+	return fmt.Sprintf("guest:claim:%d", userID)
+}
+
+func guestClaimAttemptsKey(userID uint) string {
+	return fmt.Sprintf("guest:claim:%d:attempts", userID)
+}
+
+// Код, по которому клиент при регистрации забирает гостевой аккаунт, заведённый владельцем на его телефон.
+// Владелец передаёт код клиенту сам, например по телефону. Новый код заменяет предыдущий
+func CreateGuestClaimCode() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var user models.User
+		db := database.GetGormDB()
+		redisClient, err := database.GetRedis()
+		if err != nil {
+			log.Error().Err(err).Msg("Error getting redis")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get redis"})
+			return
+		}
+
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+			return
+		}
+
+		if err := db.First(&user, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+				return
+			}
+			log.Error().Err(err).Int("id", id).Msg("Failed to get user")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user"})
+			return
+		}
+
+		if !user.IsGuest {
+			c.JSON(http.StatusConflict, gin.H{"error": "Акаунт вже зареєстровано"})
+			return
+		}
+
+		n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to generate claim code")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create claim code"})
+			return
+		}
+		code := fmt.Sprintf("%06d", n.Int64())
+
+		if _, err := redisClient.TxPipelined(c, func(pipe redis.Pipeliner) error {
+			pipe.Set(c, guestClaimKey(user.ID), code, guestClaimTTL)
+			pipe.Del(c, guestClaimAttemptsKey(user.ID))
+			return nil
+		}); err != nil {
+			log.Error().Err(err).Uint("user_id", user.ID).Msg("Failed to save claim code")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create claim code"})
+			return
+		}
+
+		log.Info().Uint("user_id", user.ID).Msg("Guest claim code created")
+
+		c.JSON(http.StatusCreated, gin.H{
+			"code":       code,
+			"expires_at": time.Now().UTC().Add(guestClaimTTL).Format(time.RFC3339),
+		})
+	}
+}
+
+// checkGuestClaim сверяет код гостевого аккаунта. После guestClaimMaxAttempts неверных попыток код сгорает,
+// и владельцу нужно выдать новый
+func checkGuestClaim(ctx context.Context, userID uint, code string) error {
+	if code == "" {
+		return errGuestClaimRequired
+	}
+
+	redisClient, err := database.GetRedis()
+	if err != nil {
+		return err
+	}
+
+	expected, err := redisClient.Get(ctx, guestClaimKey(userID)).Result()
+	if errors.Is(err, redis.Nil) {
+		return errGuestClaimInvalid
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get claim code: %w", err)
+	}
+
+	if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+		return nil
+	}
+
+	attempts, err := redisClient.Incr(ctx, guestClaimAttemptsKey(userID)).Result()
+	if err != nil {
+		return fmt.Errorf("failed to count claim attempts: %w", err)
+	}
+	redisClient.Expire(ctx, guestClaimAttemptsKey(userID), guestClaimTTL)
+	if attempts >= guestClaimMaxAttempts {
+		redisClient.Del(ctx, guestClaimKey(userID), guestClaimAttemptsKey(userID))
+	}
+	return errGuestClaimInvalid
+}
+
+// dropGuestClaim удаляет использованный код
+func dropGuestClaim(ctx context.Context, userID uint) {
+	redisClient, err := database.GetRedis()
+	if err != nil {
+		log.Error().Err(err).Msg("Error getting redis")
+		return
+	}
+	if err := redisClient.Del(ctx, guestClaimKey(userID), guestClaimAttemptsKey(userID)).Err(); err != nil {
+		log.Error().Err(err).Uint("user_id", userID).Msg("Failed to drop claim code")
+	}
+}
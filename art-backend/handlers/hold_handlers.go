@@ -0,0 +1,339 @@
+package handlers
+
+import (
+	"art/database"
+	"art/models"
+	"art/utils"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Удержание мест на слоте на HoldTTLMinutes, пока клиент оформляет запись
+func CreateHold() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req models.HoldRequest
+		var user models.User
+		db := database.GetGormDB()
+
+		if err := c.ShouldBindJSON(&req); err != nil {
+			log.Error().Err(err).Msg("Error to bind json")
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data: " + err.Error()})
+			return
+		}
+
+		slot, ok := findActivitySlot(c, db)
+		if !ok {
+			return
+		}
+
+		phoneNumber, _ := c.Get("phone_number")
+		if err := db.Where("phone_number = ?", phoneNumber).First(&user).Error; err != nil {
+			log.Error().Err(err).Msgf("Error finding user with phone number: %v", phoneNumber)
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+
+		if blocked := checkBookingBlock(c, db, user.ID); blocked {
+			return
+		}
+
+		kids, err := resolveBookingKids(db, user.ID, req.Kids, req.UserKidIDs, req.NumberOfKids)
+		if err != nil {
+			respondKidsError(c, err)
+			return
+		}
+
+		if ok := checkKidsAge(c, db, slot, kids); !ok {
+			return
+		}
+
+		settings, err := loadStudioSettings(db)
+		if err != nil {
+			log.Error().Err(err).Msg("Error loading studio settings")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load studio settings"})
+			return
+		}
+
+		now := time.Now().UTC()
+
+		tx := db.Begin()
+
+		// Под блокировкой слота холд конкурирует за места с обычными записями на равных
+		lockedSlot, err := lockFreeSeats(tx, slot.ID, len(kids), 0)
+		if err != nil {
+			tx.Rollback()
+			respondReserveError(c, lockedSlot, err)
+			return
+		}
+
+		var existing int64
+		if err := tx.Model(&models.SlotHold{}).
+			Where("slot_id = ? AND user_id = ? AND status = ? AND expires_at > ?", slot.ID, user.ID, models.HoldStatusActive, now).
+			Count(&existing).Error; err != nil {
+			tx.Rollback()
+			log.Error().Err(err).Msg("Error checking existing hold")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create hold"})
+			return
+		}
+		if existing > 0 {
+			tx.Rollback()
+			c.JSON(http.StatusConflict, gin.H{"error": "Ви вже тримаєте місця на цьому занятті"})
+			return
+		}
+
+		duplicate, err := findDuplicateKid(tx, slot.ID, kids)
+		if err != nil {
+			tx.Rollback()
+			log.Error().Err(err).Msg("Ошибка проверки дубля записи")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка сервера при проверке дубля"})
+			return
+		}
+		if duplicate != nil {
+			tx.Rollback()
+			c.JSON(http.StatusConflict, gin.H{
+				"error":    "Record for this kid on this slot already exist",
+				"kid_name": duplicate.Name,
+			})
+			return
+		}
+
+		hold := models.SlotHold{
+			SlotID:       slot.ID,
+			UserID:       user.ID,
+			NumberOfKids: uint(len(kids)),
+			Kids:         kids,
+			ExpiresAt:    now.Add(time.Duration(settings.HoldTTLMinutes) * time.Minute),
+			Status:       models.HoldStatusActive,
+		}
+
+		if err := tx.Create(&hold).Error; err != nil {
+			tx.Rollback()
+			log.Error().Err(err).Msg("Failed to create hold")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create hold"})
+			return
+		}
+
+		if err := tx.Commit().Error; err != nil {
+			log.Error().Err(err).Msg("Commit failed for create hold")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Transaction failed"})
+			return
+		}
+
+		log.Info().Uint("hold_id", hold.ID).Uint("slot_id", slot.ID).Time("expires_at", hold.ExpiresAt).Msg("Seats held")
+
+		c.JSON(http.StatusCreated, models.ToHoldResponse(hold, now))
+	}
+}
+
+// Превращение холда в запись. Истёкший холд подтвердить нельзя, места уже отпущены
+func ConfirmHold() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var user models.User
+		var hold models.SlotHold
+		db := database.GetGormDB()
+		redisClient, err := database.GetRedis()
+		if err != nil {
+			log.Error().Err(err).Msg("Error getting redis")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get redis"})
+			return
+		}
+
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+			return
+		}
+
+		phoneNumber, _ := c.Get("phone_number")
+		if err := db.Where("phone_number = ?", phoneNumber).First(&user).Error; err != nil {
+			log.Error().Err(err).Msgf("Error finding user with phone number: %v", phoneNumber)
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+
+		if blocked := checkBookingBlock(c, db, user.ID); blocked {
+			return
+		}
+
+		tx := db.Begin()
+		defer func() {
+			if r := recover(); r != nil {
+				tx.Rollback()
+			}
+		}()
+
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND user_id = ?", id, user.ID).
+			First(&hold).Error; err != nil {
+			tx.Rollback()
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Hold not found"})
+				return
+			}
+			log.Error().Err(err).Int("id", id).Msg("Failed to get hold")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch hold"})
+			return
+		}
+
+		if !hold.IsActive(time.Now().UTC()) {
+			tx.Rollback()
+			c.JSON(http.StatusGone, gin.H{
+				"error":  "Час утримання місць вичерпано, почніть запис заново",
+				"reason": "hold_expired",
+			})
+			return
+		}
+
+		var slot models.ActivitySlot
+		if err := tx.Select("id", "activity_id").First(&slot, hold.SlotID).Error; err != nil {
+			tx.Rollback()
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				err = errSlotNotFound
+			}
+			respondReserveError(c, slot, err)
+			return
+		}
+
+		// Цена считается на момент создания холда, его места не считаются занятыми
+		record, slot, err := createSlotRecord(tx, slotBooking{
+			UserID:      &user.ID,
+			PhoneNumber: user.PhoneNumber,
+			ParentName:  user.Name + " " + user.Surname,
+			ActivityID:  slot.ActivityID,
+			SlotID:      hold.SlotID,
+			Kids:        hold.Kids,
+			HoldID:      hold.ID,
+			QuotedAt:    hold.CreatedAt,
+		})
+		if err != nil {
+			tx.Rollback()
+			respondBookingError(c, slot, err)
+			return
+		}
+
+		if err := tx.Model(&hold).Updates(map[string]interface{}{
+			"status":    models.HoldStatusConfirmed,
+			"record_id": record.ID,
+		}).Error; err != nil {
+			tx.Rollback()
+			log.Error().Err(err).Uint("hold_id", hold.ID).Msg("Failed to confirm hold")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to confirm hold"})
+			return
+		}
+
+		if err := tx.Commit().Error; err != nil {
+			log.Error().Err(err).Msg("Commit failed for confirm hold")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Transaction failed"})
+			return
+		}
+
+		log.Info().Uint("hold_id", hold.ID).Uint("record_id", record.ID).Msg("Hold confirmed")
+
+		if redisClient != nil {
+			utils.InvalidateCache(c, "/records", "records:all:*", fmt.Sprintf("client:records:%s:*", record.PhoneNumber))
+		}
+
+		c.JSON(http.StatusCreated, recordCreatedResponse(record))
+	}
+}
+
+// Досрочное освобождение мест клиентом
+func ReleaseHold() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var user models.User
+		db := database.GetGormDB()
+
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+			return
+		}
+
+		phoneNumber, _ := c.Get("phone_number")
+		if err := db.Where("phone_number = ?", phoneNumber).First(&user).Error; err != nil {
+			log.Error().Err(err).Msgf("Error finding user with phone number: %v", phoneNumber)
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+
+		res := db.Model(&models.SlotHold{}).
+			Where("id = ? AND user_id = ? AND status = ?", id, user.ID, models.HoldStatusActive).
+			Update("status", models.HoldStatusReleased)
+		if res.Error != nil {
+			log.Error().Err(res.Error).Int("id", id).Msg("Failed to release hold")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to release hold"})
+			return
+		}
+		if res.RowsAffected == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Hold not found"})
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// Действующие холды клиента
+func GetMyHolds() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var user models.User
+		db := database.GetGormDB()
+
+		phoneNumber, _ := c.Get("phone_number")
+		if err := db.Where("phone_number = ?", phoneNumber).First(&user).Error; err != nil {
+			log.Error().Err(err).Msgf("Error finding user with phone number: %v", phoneNumber)
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+
+		now := time.Now().UTC()
+
+		var holds []models.SlotHold
+		if err := db.Where("user_id = ? AND status = ? AND expires_at > ?", user.ID, models.HoldStatusActive, now).
+			Order("expires_at ASC").
+			Find(&holds).Error; err != nil {
+			log.Error().Err(err).Uint("user_id", user.ID).Msg("Failed to get holds")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch holds"})
+			return
+		}
+
+		response := make([]models.HoldResponse, len(holds))
+		for i, hold := range holds {
+			response[i] = models.ToHoldResponse(hold, now)
+		}
+
+		c.JSON(http.StatusOK, gin.H{"holds": response})
+	}
+}
+
+// respondReserveError отвечает клиенту по ошибкам резервирования мест в слоте
+func respondReserveError(c *gin.Context, slot models.ActivitySlot, err error) {
+	c.JSON(reserveErrorBody(slot, err))
+}
+
+// reserveErrorBody — код и тело ответа по ошибке резервирования мест
+func reserveErrorBody(slot models.ActivitySlot, err error) (int, gin.H) {
+	switch {
+	case errors.Is(err, errSlotNotFound):
+		return http.StatusNotFound, gin.H{"error": "Слот не найден"}
+	case errors.Is(err, errSlotInPast):
+		return http.StatusBadRequest, gin.H{"error": "Дата занятия должна быть в будущем"}
+	case errors.Is(err, errSlotFull):
+		return http.StatusBadRequest, gin.H{
+			"error":              "Места закончились",
+			"free_places":        slot.FreePlaces(),
+			"waitlist_available": true,
+		}
+	default:
+		log.Error().Err(err).Msg("Error reserving slot places")
+		return http.StatusInternalServerError, gin.H{"error": "Failed to reserve slot places"}
+	}
+}
@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// bindJSONFields разбирает тело запроса в obj и возвращает ключи, которые прислал клиент.
+// Нужен при частичном обновлении, где отсутствующее поле и явный null значат разное
+func bindJSONFields(c *gin.Context, obj interface{}) (map[string]json.RawMessage, error) {
+	if err := c.ShouldBindBodyWith(obj, binding.JSON); err != nil {
+		return nil, err
+	}
+
+	body, ok := c.Get(gin.BodyBytesKey)
+	if !ok {
+		return nil, errors.New("request body is not cached")
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body.([]byte), &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// hasJSONField сообщает, прислал ли клиент ключ, в том числе со значением null
+func hasJSONField(fields map[string]json.RawMessage, key string) bool {
+	_, ok := fields[key]
+	return ok
+}
@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"art/database"
+	"art/models"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// noShowBlockUntil возвращает, до какого момента самозапись закрыта по правилам неявок студии.
+// Блок начинается с неявки, которой набрался лимит за NoShowPeriodDays, и длится NoShowBlockDays
+func noShowBlockUntil(db *gorm.DB, userID uint, settings models.StudioSettings, now time.Time) (time.Time, []models.NoShowEvent, error) {
+	var until time.Time
+	var events []models.NoShowEvent
+
+	if settings.NoShowLimit <= 0 || settings.NoShowBlockDays <= 0 {
+		return until, events, nil
+	}
+
+	period := time.Duration(settings.NoShowPeriodDays) * 24 * time.Hour
+	block := time.Duration(settings.NoShowBlockDays) * 24 * time.Hour
+
+	if err := db.Where("user_id = ? AND occurred_at >= ?", userID, now.Add(-period-block)).
+		Order("occurred_at ASC").
+		Find(&events).Error; err != nil {
+		return until, events, err
+	}
+
+	for i := settings.NoShowLimit - 1; i < len(events); i++ {
+		first := events[i-settings.NoShowLimit+1].OccurredAt
+		last := events[i].OccurredAt
+		if last.Sub(first) <= period && last.Add(block).After(until) {
+			until = last.Add(block)
+		}
+	}
+
+	return until, events, nil
+}
+
+// checkBookingBlock сам отвечает клиенту отказом, если самозапись для него закрыта из-за неявок
+func checkBookingBlock(c *gin.Context, db *gorm.DB, userID uint) bool {
+	settings, err := loadStudioSettings(db)
+	if err != nil {
+		log.Error().Err(err).Msg("Error loading studio settings")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load studio settings"})
+		return true
+	}
+
+	now := time.Now().UTC()
+	until, _, err := noShowBlockUntil(db, userID, settings, now)
+	if err != nil {
+		log.Error().Err(err).Uint("user_id", userID).Msg("Error evaluating no-show policy")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check booking restrictions"})
+		return true
+	}
+
+	if until.After(now) {
+		log.Info().Uint("user_id", userID).Time("blocked_until", until).Msg("Booking refused by no-show policy")
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": fmt.Sprintf("Самостійний запис тимчасово недоступний: %d неявки за %d днів. Запис відкриється %s",
+				settings.NoShowLimit, settings.NoShowPeriodDays, until.Format("02.01.2006 15:04")),
+			"reason":        "no_show_blocked",
+			"blocked_until": until.Format(time.RFC3339),
+		})
+		return true
+	}
+	return false
+}
+
+// recordNoShow фиксирует неявку по записи. Повторная фиксация той же записи игнорируется.
+// Гостю без аккаунта блокировать нечего, поэтому его неявки не копятся
+func recordNoShow(tx *gorm.DB, record *models.Record, reason string, occurredAt time.Time) error {
+	if record.UserID == nil {
+		return nil
+	}
+	event := models.NoShowEvent{
+		UserID:     *record.UserID,
+		RecordID:   record.ID,
+		SlotID:     record.SlotID,
+		Reason:     reason,
+		OccurredAt: occurredAt,
+	}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&event).Error; err != nil {
+		return fmt.Errorf("failed to save no-show event: %w", err)
+	}
+	return nil
+}
+
+// syncNoShow приводит неявку записи в соответствие с отметками: все отмеченные дети отсутствовали без причины — неявка
+func syncNoShow(tx *gorm.DB, recordID uint, slotStart time.Time) error {
+	var record models.Record
+	if err := tx.First(&record, recordID).Error; err != nil {
+		return fmt.Errorf("failed to find record: %w", err)
+	}
+
+	var counts struct {
+		Absent  int64
+		Present int64
+	}
+	if err := tx.Model(&models.Attendance{}).
+		Select("COUNT(*) FILTER (WHERE status = ?) AS absent, COUNT(*) FILTER (WHERE status IN ?) AS present",
+			models.AttendanceAbsent, []string{models.AttendancePresent, models.AttendanceLate}).
+		Where("record_id = ?", record.ID).
+		Scan(&counts).Error; err != nil {
+		return fmt.Errorf("failed to count attendance: %w", err)
+	}
+
+	if counts.Absent > 0 && counts.Present == 0 {
+		if err := markRecordOutcome(tx, &record, models.RecordStatusNoShow); err != nil {
+			return err
+		}
+		return recordNoShow(tx, &record, models.NoShowReasonAbsent, slotStart)
+	}
+	if counts.Present > 0 {
+		if err := markRecordOutcome(tx, &record, models.RecordStatusCompleted); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Where("record_id = ? AND reason = ?", record.ID, models.NoShowReasonAbsent).
+		Delete(&models.NoShowEvent{}).Error; err != nil {
+		return fmt.Errorf("failed to clear no-show event: %w", err)
+	}
+	return nil
+}
+
+// markRecordOutcome выставляет записи итог занятия по отметкам, если переход допустим
+func markRecordOutcome(tx *gorm.DB, record *models.Record, status string) error {
+	if !models.CanTransitionRecord(record.Status, status) {
+		return nil
+	}
+	return setRecordStatus(tx, record, status, "", nil)
+}
+
+// chargeVisit списывает визит абонемента за запись, если он ещё не списан
+func chargeVisit(tx *gorm.DB, record *models.Record) error {
+	if record.SubscriptionID == nil || record.VisitCounted {
+		return nil
+	}
+
+	var subscription models.Subscription
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&subscription, *record.SubscriptionID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Warn().Uint("subscription_id", *record.SubscriptionID).Msg("subscription missing, visit not charged")
+			return nil
+		}
+		return fmt.Errorf("failed to lock subscription: %w", err)
+	}
+
+	if subscription.VisitsUsed >= subscription.VisitsTotal {
+		log.Warn().Uint("subscription_id", subscription.ID).Uint("record_id", record.ID).Msg("Subscription exhausted, visit not counted")
+		return nil
+	}
+
+	if err := tx.Model(&subscription).UpdateColumn("visits_used", gorm.Expr("visits_used + 1")).Error; err != nil {
+		return fmt.Errorf("failed to update subscription visits: %w", err)
+	}
+	if err := tx.Model(record).UpdateColumn("visit_counted", true).Error; err != nil {
+		return fmt.Errorf("failed to update record visit flag: %w", err)
+	}
+	record.VisitCounted = true
+	return nil
+}
+
+// Неявки клиента за текущий период и срок блокировки самозаписи, если она действует
+func GetMyNoShows() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var user models.User
+		db := database.GetGormDB()
+
+		phoneNumber, ok := c.Get("phone_number")
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		if err := db.Where("phone_number = ?", phoneNumber).First(&user).Error; err != nil {
+			log.Error().Err(err).Msgf("Error finding user with phone number: %v", phoneNumber)
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+
+		settings, err := loadStudioSettings(db)
+		if err != nil {
+			log.Error().Err(err).Msg("Error loading studio settings")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load studio settings"})
+			return
+		}
+
+		now := time.Now().UTC()
+		until, events, err := noShowBlockUntil(db, user.ID, settings, now)
+		if err != nil {
+			log.Error().Err(err).Uint("user_id", user.ID).Msg("Error evaluating no-show policy")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check booking restrictions"})
+			return
+		}
+
+		resp := gin.H{
+			"no_shows":    events,
+			"limit":       settings.NoShowLimit,
+			"period_days": settings.NoShowPeriodDays,
+			"blocked":     until.After(now),
+		}
+		if until.After(now) {
+			resp["blocked_until"] = until.Format(time.RFC3339)
+		}
+
+		c.JSON(http.StatusOK, resp)
+	}
+}
@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"art/database"
+	"art/models"
+	"art/utils"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+// Checkout оформляет корзину: записи на все слоты создаются в одной транзакции или не создаётся ни одна
+func Checkout() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req models.OrderRequest
+		db := database.GetGormDB()
+
+		if err := c.ShouldBindJSON(&req); err != nil {
+			log.Error().Err(err).Msg("Error to bind json")
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data: " + err.Error()})
+			return
+		}
+
+		phoneNumber := c.GetString("phone_number")
+
+		var user models.User
+		if err := db.Where("phone_number = ?", phoneNumber).First(&user).Error; err != nil {
+			log.Error().Err(err).Msg("Error to find user by phone number")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user"})
+			return
+		}
+
+		if blocked := checkBookingBlock(c, db, user.ID); blocked {
+			return
+		}
+
+		slotIDs := make([]uint, len(req.Items))
+		itemKids := make([][]models.Kid, len(req.Items))
+		for i, item := range req.Items {
+			kids, err := resolveBookingKids(db, user.ID, item.Kids, item.UserKidIDs, item.NumberOfKids)
+			if err != nil {
+				status, resp := kidsErrorBody(err)
+				resp["item_index"] = i
+				c.JSON(status, resp)
+				return
+			}
+			itemKids[i] = kids
+			slotIDs[i] = item.SlotID
+		}
+
+		tx := db.Begin()
+		defer func() {
+			if r := recover(); r != nil {
+				tx.Rollback()
+			}
+		}()
+
+		// Все слоты корзины блокируются заранее по возрастанию id, поэтому две корзины
+		// с пересекающимися слотами не захватят их в разном порядке
+		if err := lockSlots(tx, slotIDs...); err != nil {
+			tx.Rollback()
+			log.Error().Err(err).Msg("Failed to lock order slots")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reserve slot places"})
+			return
+		}
+
+		order := models.Order{
+			UserID:      user.ID,
+			PhoneNumber: phoneNumber,
+			ParentName:  user.Name + " " + user.Surname,
+		}
+		if err := tx.Create(&order).Error; err != nil {
+			tx.Rollback()
+			log.Error().Err(err).Msg("Failed to create order")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create order"})
+			return
+		}
+
+		for i, item := range req.Items {
+			record, slot, err := createSlotRecord(tx, slotBooking{
+				UserID:      &user.ID,
+				PhoneNumber: phoneNumber,
+				ParentName:  order.ParentName,
+				ActivityID:  item.ActivityID,
+				SlotID:      item.SlotID,
+				Kids:        itemKids[i],
+				OrderID:     &order.ID,
+			})
+			if err != nil {
+				tx.Rollback()
+				status, resp := bookingErrorBody(slot, err)
+				resp["item_index"] = i // Какая позиция корзины не прошла
+				resp["slot_id"] = item.SlotID
+				c.JSON(status, resp)
+				return
+			}
+			order.Records = append(order.Records, record)
+			order.TotalPrice += record.TotalPrice
+		}
+
+		if err := tx.Model(&order).UpdateColumn("total_price", order.TotalPrice).Error; err != nil {
+			tx.Rollback()
+			log.Error().Err(err).Uint("order_id", order.ID).Msg("Failed to save order total")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create order"})
+			return
+		}
+
+		if err := tx.Commit().Error; err != nil {
+			log.Error().Err(err).Msg("Commit failed for create order")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Transaction failed"})
+			return
+		}
+
+		utils.InvalidateCache(c, "/records", "records:all:*", fmt.Sprintf("client:records:%s:*", phoneNumber))
+
+		log.Info().Uint("order_id", order.ID).Int("items", len(order.Records)).Uint("total", order.TotalPrice).Msg("Order created")
+		c.JSON(http.StatusCreated, models.ToOrderResponse(order))
+	}
+}
+
+func GetMyOrders() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var user models.User
+		db := database.GetGormDB()
+
+		phoneNumber, _ := c.Get("phone_number")
+		if err := db.Where("phone_number = ?", phoneNumber).First(&user).Error; err != nil {
+			log.Error().Err(err).Msgf("Error finding user with phone number: %v", phoneNumber)
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+
+		var orders []models.Order
+		if err := db.Where("user_id = ?", user.ID).
+			Preload("Records", func(db *gorm.DB) *gorm.DB {
+				return db.Order("id ASC")
+			}).
+			Preload("Records.Kids").
+			Order("created_at DESC").
+			Find(&orders).Error; err != nil {
+			log.Error().Err(err).Uint("user_id", user.ID).Msg("Failed to get orders")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch orders"})
+			return
+		}
+
+		response := make([]models.OrderResponse, len(orders))
+		for i, order := range orders {
+			response[i] = models.ToOrderResponse(order)
+		}
+
+		c.JSON(http.StatusOK, gin.H{"orders": response})
+	}
+}
+
+func GetMyOrderByID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var user models.User
+		db := database.GetGormDB()
+
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+			return
+		}
+
+		phoneNumber, _ := c.Get("phone_number")
+		if err := db.Where("phone_number = ?", phoneNumber).First(&user).Error; err != nil {
+			log.Error().Err(err).Msgf("Error finding user with phone number: %v", phoneNumber)
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+
+		var order models.Order
+		if err := db.Where("user_id = ?", user.ID).
+			Preload("Records", func(db *gorm.DB) *gorm.DB {
+				return db.Order("id ASC")
+			}).
+			Preload("Records.Kids").
+			First(&order, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+				return
+			}
+			log.Error().Err(err).Int("id", id).Msg("Failed to get order")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch order"})
+			return
+		}
+
+		c.JSON(http.StatusOK, models.ToOrderResponse(order))
+	}
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"art/models"
+	"art/pricing"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// priceRules собирает правила цены из настроек студии. Цена слота применяется первой,
+// процентные скидки считаются по очереди от уже уменьшенной суммы
+func priceRules(settings models.StudioSettings) []pricing.Rule {
+	return []pricing.Rule{
+		pricing.SlotPriceRule{},
+		pricing.SiblingRule{Percent: settings.SiblingDiscountPercent},
+		pricing.EarlyBirdRule{Days: settings.EarlyBirdDays, Percent: settings.EarlyBirdDiscountPercent},
+		pricing.MemberRule{Percent: settings.MemberDiscountPercent},
+	}
+}
+
+// quoteRecord считает цену разовой записи клиента на слот по правилам студии.
+// extra — правила конкретной записи (промокод), применяются после правил студии
+func quoteRecord(db *gorm.DB, userID uint, activity models.Activity, slot models.ActivitySlot, kids uint, bookedAt time.Time, extra ...pricing.Rule) (pricing.Breakdown, error) {
+	settings, err := loadStudioSettings(db)
+	if err != nil {
+		return pricing.Breakdown{}, fmt.Errorf("failed to load studio settings: %w", err)
+	}
+
+	var members int64
+	if settings.MemberDiscountPercent > 0 {
+		if err := db.Model(&models.Subscription{}).
+			Where("user_id = ? AND end_date > ? AND visits_used < visits_total", userID, bookedAt).
+			Count(&members).Error; err != nil {
+			return pricing.Breakdown{}, fmt.Errorf("failed to check active subscriptions: %w", err)
+		}
+	}
+
+	return pricing.NewEngine(append(priceRules(settings), extra...)...).Calculate(pricing.Quote{
+		ActivityPrice: activity.Price,
+		SlotPrice:     slot.PriceOverride,
+		Kids:          kids,
+		BookedAt:      bookedAt,
+		SlotStart:     slot.StartTime,
+		IsMember:      members > 0,
+	}), nil
+}
@@ -0,0 +1,324 @@
+package handlers
+
+import (
+	"art/database"
+	"art/models"
+	"art/pricing"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	errPromoNotFound      = errors.New("promo code not found")
+	errPromoNotValid      = errors.New("promo code is not valid now")
+	errPromoExhausted     = errors.New("promo code uses exhausted")
+	errPromoUserLimit     = errors.New("promo code user limit reached")
+	errPromoNotApplicable = errors.New("promo code does not apply")
+)
+
+// lockPromoCode находит промокод и блокирует его строку до конца транзакции, поэтому
+// проверка лимитов и списание применения не пересекаются с параллельными запросами.
+// Для записи subTypeID пустой, для абонемента activityID — занятие его типа
+func lockPromoCode(tx *gorm.DB, code string, userID, activityID uint, subTypeID *uint, now time.Time) (models.PromoCode, error) {
+	var promo models.PromoCode
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("UPPER(code) = UPPER(?)", strings.TrimSpace(code)).
+		First(&promo).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return promo, errPromoNotFound
+		}
+		return promo, fmt.Errorf("failed to lock promo code: %w", err)
+	}
+
+	if !promo.IsActive ||
+		(promo.ValidFrom != nil && now.Before(*promo.ValidFrom)) ||
+		(promo.ValidTo != nil && !now.Before(*promo.ValidTo)) {
+		return promo, errPromoNotValid
+	}
+
+	if promo.ActivityID != nil && *promo.ActivityID != activityID {
+		return promo, errPromoNotApplicable
+	}
+	if promo.SubscriptionTypeID != nil && (subTypeID == nil || *promo.SubscriptionTypeID != *subTypeID) {
+		return promo, errPromoNotApplicable
+	}
+
+	if promo.MaxUses > 0 && promo.UsesCount >= promo.MaxUses {
+		return promo, errPromoExhausted
+	}
+
+	if promo.PerUserLimit > 0 {
+		var used int64
+		if err := tx.Model(&models.PromoRedemption{}).
+			Where("promo_code_id = ? AND user_id = ?", promo.ID, userID).
+			Count(&used).Error; err != nil {
+			return promo, fmt.Errorf("failed to count promo redemptions: %w", err)
+		}
+		if used >= int64(promo.PerUserLimit) {
+			return promo, errPromoUserLimit
+		}
+	}
+
+	return promo, nil
+}
+
+func promoRule(promo models.PromoCode) pricing.PromoRule {
+	rule := pricing.PromoRule{Code: strings.ToUpper(promo.Code)}
+	if promo.DiscountType == models.PromoTypePercent {
+		rule.Percent = int(promo.Value)
+	} else {
+		rule.Amount = promo.Value
+	}
+	return rule
+}
+
+// redeemPromo фиксирует применение промокода. Строка промокода уже заблокирована lockPromoCode
+func redeemPromo(tx *gorm.DB, promo *models.PromoCode, redemption models.PromoRedemption) error {
+	redemption.PromoCodeID = promo.ID
+	if err := tx.Create(&redemption).Error; err != nil {
+		return fmt.Errorf("failed to save promo redemption: %w", err)
+	}
+	if err := tx.Model(promo).UpdateColumn("uses_count", gorm.Expr("uses_count + 1")).Error; err != nil {
+		return fmt.Errorf("failed to update promo uses: %w", err)
+	}
+	return nil
+}
+
+// releasePromo возвращает применение промокода при отмене записи
+func releasePromo(tx *gorm.DB, recordID uint) error {
+	var redemptions []models.PromoRedemption
+	if err := tx.Where("record_id = ?", recordID).Find(&redemptions).Error; err != nil {
+		return fmt.Errorf("failed to find promo redemptions: %w", err)
+	}
+	for _, redemption := range redemptions {
+		if err := tx.Model(&models.PromoCode{}).
+			Where("id = ?", redemption.PromoCodeID).
+			UpdateColumn("uses_count", gorm.Expr("GREATEST(uses_count - 1, 0)")).Error; err != nil {
+			return fmt.Errorf("failed to release promo use: %w", err)
+		}
+		if err := tx.Delete(&redemption).Error; err != nil {
+			return fmt.Errorf("failed to delete promo redemption: %w", err)
+		}
+	}
+	return nil
+}
+
+func respondPromoError(c *gin.Context, err error) {
+	c.JSON(promoErrorBody(err))
+}
+
+func promoErrorBody(err error) (int, gin.H) {
+	switch {
+	case errors.Is(err, errPromoNotFound):
+		return http.StatusNotFound, gin.H{"error": "Промокод не знайдено", "reason": "promo_not_found"}
+	case errors.Is(err, errPromoNotValid):
+		return http.StatusBadRequest, gin.H{"error": "Промокод зараз не діє", "reason": "promo_not_valid"}
+	case errors.Is(err, errPromoExhausted):
+		return http.StatusConflict, gin.H{"error": "Промокод вже використано максимальну кількість разів", "reason": "promo_exhausted"}
+	case errors.Is(err, errPromoUserLimit):
+		return http.StatusConflict, gin.H{"error": "Ви вже використали цей промокод", "reason": "promo_user_limit"}
+	case errors.Is(err, errPromoNotApplicable):
+		return http.StatusBadRequest, gin.H{"error": "Промокод не діє для цього заняття або абонемента", "reason": "promo_not_applicable"}
+	default:
+		log.Error().Err(err).Msg("Error applying promo code")
+		return http.StatusInternalServerError, gin.H{"error": "Failed to apply promo code"}
+	}
+}
+
+func applyPromoInput(promo *models.PromoCode, input models.PromoCodeInput) {
+	promo.Code = strings.ToUpper(input.Code)
+	promo.DiscountType = input.DiscountType
+	promo.Value = input.Value
+	promo.ValidFrom = input.ValidFrom
+	promo.ValidTo = input.ValidTo
+	promo.MaxUses = input.MaxUses
+	promo.PerUserLimit = input.PerUserLimit
+	promo.ActivityID = input.ActivityID
+	promo.SubscriptionTypeID = input.SubscriptionTypeID
+	if input.IsActive != nil {
+		promo.IsActive = *input.IsActive
+	}
+}
+
+// validatePromoInput проверяет то, что не выражается тегами binding, и сам отвечает 400
+func validatePromoInput(c *gin.Context, input models.PromoCodeInput) bool {
+	if input.DiscountType == models.PromoTypePercent && input.Value > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Percent discount must not exceed 100"})
+		return false
+	}
+	if input.ValidFrom != nil && input.ValidTo != nil && !input.ValidFrom.Before(*input.ValidTo) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "valid_from must be before valid_to"})
+		return false
+	}
+	return true
+}
+
+func CreatePromoCode() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input models.PromoCodeInput
+		db := database.GetGormDB()
+
+		if err := c.ShouldBindJSON(&input); err != nil {
+			log.Error().Err(err).Msg("Error binding JSON")
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if !validatePromoInput(c, input) {
+			return
+		}
+
+		promo := models.PromoCode{IsActive: true}
+		applyPromoInput(&promo, input)
+
+		if err := db.Create(&promo).Error; err != nil {
+			if isUniqueViolation(err) {
+				c.JSON(http.StatusConflict, gin.H{"error": "Promo code already exists"})
+				return
+			}
+			log.Error().Err(err).Msg("Failed to create promo code")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create promo code"})
+			return
+		}
+
+		log.Info().Uint("id", promo.ID).Str("code", promo.Code).Msg("Promo code created")
+		c.JSON(http.StatusCreated, promo)
+	}
+}
+
+func GetPromoCodes() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var promos []models.PromoCode
+		db := database.GetGormDB()
+
+		query := db.Order("id DESC")
+		if active := c.Query("active"); active != "" {
+			isActive, err := strconv.ParseBool(active)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid active filter"})
+				return
+			}
+			query = query.Where("is_active = ?", isActive)
+		}
+
+		if err := query.Find(&promos).Error; err != nil {
+			log.Error().Err(err).Msg("Failed to fetch promo codes")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch promo codes"})
+			return
+		}
+
+		c.JSON(http.StatusOK, promos)
+	}
+}
+
+func UpdatePromoCode() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input models.PromoCodeInput
+		var promo models.PromoCode
+		db := database.GetGormDB()
+
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+			return
+		}
+
+		if err := c.ShouldBindJSON(&input); err != nil {
+			log.Error().Err(err).Msg("Error binding JSON")
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if !validatePromoInput(c, input) {
+			return
+		}
+
+		tx := db.Begin()
+
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&promo, id).Error; err != nil {
+			tx.Rollback()
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Promo code not found"})
+				return
+			}
+			log.Error().Err(err).Int("id", id).Msg("Failed to find promo code")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch promo code"})
+			return
+		}
+
+		applyPromoInput(&promo, input)
+
+		if err := tx.Save(&promo).Error; err != nil {
+			tx.Rollback()
+			if isUniqueViolation(err) {
+				c.JSON(http.StatusConflict, gin.H{"error": "Promo code already exists"})
+				return
+			}
+			log.Error().Err(err).Int("id", id).Msg("Failed to update promo code")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update promo code"})
+			return
+		}
+
+		if err := tx.Commit().Error; err != nil {
+			log.Error().Err(err).Msg("Commit failed for update promo code")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Transaction failed"})
+			return
+		}
+
+		c.JSON(http.StatusOK, promo)
+	}
+}
+
+func DeletePromoCode() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		db := database.GetGormDB()
+
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+			return
+		}
+
+		res := db.Delete(&models.PromoCode{}, id)
+		if res.Error != nil {
+			log.Error().Err(res.Error).Int("id", id).Msg("Failed to delete promo code")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete promo code"})
+			return
+		}
+		if res.RowsAffected == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Promo code not found"})
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// Кто и когда применял промокод
+func GetPromoRedemptions() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var redemptions []models.PromoRedemption
+		db := database.GetGormDB()
+
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+			return
+		}
+
+		if err := db.Where("promo_code_id = ?", id).Order("created_at DESC").Find(&redemptions).Error; err != nil {
+			log.Error().Err(err).Int("id", id).Msg("Failed to fetch promo redemptions")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch promo redemptions"})
+			return
+		}
+
+		c.JSON(http.StatusOK, redemptions)
+	}
+}
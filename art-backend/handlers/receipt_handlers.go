@@ -0,0 +1,318 @@
+package handlers
+
+import (
+	"art/database"
+	"art/models"
+	"art/receipts"
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const receiptDateLayout = "02.01.2006 15:04"
+
+// issueReceipt выдаёт квитанцию записи или абонементу, а если она уже есть — возвращает её.
+// Номер берётся из счётчика года под блокировкой строки, поэтому параллельные выдачи идут
+// по очереди, а откат транзакции не оставляет пропуска в нумерации
+func issueReceipt(tx *gorm.DB, recordID, subscriptionID *uint, total uint, now time.Time) (models.Receipt, error) {
+	var receipt models.Receipt
+	year := now.Year()
+
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.ReceiptCounter{Year: year}).Error; err != nil {
+		return receipt, fmt.Errorf("failed to init receipt counter: %w", err)
+	}
+	var counter models.ReceiptCounter
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&counter, "year = ?", year).Error; err != nil {
+		return receipt, fmt.Errorf("failed to lock receipt counter: %w", err)
+	}
+
+	query := tx.Model(&models.Receipt{})
+	if recordID != nil {
+		query = query.Where("record_id = ?", *recordID)
+	} else {
+		query = query.Where("subscription_id = ?", *subscriptionID)
+	}
+	err := query.First(&receipt).Error
+	if err == nil {
+		return receipt, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return receipt, fmt.Errorf("failed to find receipt: %w", err)
+	}
+
+	counter.LastNumber++
+	if err := tx.Model(&counter).UpdateColumn("last_number", counter.LastNumber).Error; err != nil {
+		return receipt, fmt.Errorf("failed to update receipt counter: %w", err)
+	}
+
+	receipt = models.Receipt{
+		Number:         models.ReceiptNumber(year, counter.LastNumber),
+		Year:           year,
+		Seq:            counter.LastNumber,
+		RecordID:       recordID,
+		SubscriptionID: subscriptionID,
+		Total:          total,
+		IssuedAt:       now,
+	}
+	if err := tx.Create(&receipt).Error; err != nil {
+		return receipt, fmt.Errorf("failed to create receipt: %w", err)
+	}
+	return receipt, nil
+}
+
+// issueReceiptTx выдаёт квитанцию в отдельной транзакции
+func issueReceiptTx(db *gorm.DB, recordID, subscriptionID *uint, total uint) (models.Receipt, error) {
+	tx := db.Begin()
+	receipt, err := issueReceipt(tx, recordID, subscriptionID, total, time.Now().UTC())
+	if err != nil {
+		tx.Rollback()
+		return receipt, err
+	}
+	if err := tx.Commit().Error; err != nil {
+		return receipt, fmt.Errorf("commit failed for issue receipt: %w", err)
+	}
+	return receipt, nil
+}
+
+// receiptViewer возвращает id клиента, который запрашивает документ. Владельцу id не нужен
+func receiptViewer(c *gin.Context, db *gorm.DB) (uint, bool, bool) {
+	if c.GetString("role") == "owner" {
+		return 0, true, true
+	}
+
+	var user models.User
+	phoneNumber, _ := c.Get("phone_number")
+	if err := db.Where("phone_number = ?", phoneNumber).First(&user).Error; err != nil {
+		log.Error().Err(err).Msgf("Error finding user with phone number: %v", phoneNumber)
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return 0, false, false
+	}
+	return user.ID, false, true
+}
+
+func studioDetails(settings models.StudioSettings) receipts.Studio {
+	return receipts.Studio{
+		Name:    settings.StudioName,
+		Address: settings.StudioAddress,
+		Phone:   settings.StudioPhone,
+		TaxID:   settings.StudioTaxID,
+	}
+}
+
+func sendReceipt(c *gin.Context, doc receipts.Document, filename string) {
+	var buf bytes.Buffer
+	if err := receipts.Render(&buf, doc); err != nil {
+		log.Error().Err(err).Str("file", filename).Msg("Failed to render receipt")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render receipt"})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Data(http.StatusOK, "application/pdf", buf.Bytes())
+}
+
+// Квитанция об оплате разовой записи. По абонементу и для записи, ещё не подтверждённой
+// студией, отдаётся подтверждение записи без номера
+func GetRecordReceipt() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var record models.Record
+		db := database.GetGormDB()
+
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+			return
+		}
+
+		userID, isOwner, ok := receiptViewer(c, db)
+		if !ok {
+			return
+		}
+
+		query := db.Preload("Kids")
+		if !isOwner {
+			query = query.Where("user_id = ?", userID)
+		}
+		if err := query.First(&record, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Record not found"})
+				return
+			}
+			log.Error().Err(err).Int("id", id).Msg("Failed to get record for receipt")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error to find record"})
+			return
+		}
+		record.FillDetailKids()
+
+		if models.IsCancelledRecordStatus(record.Status) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Record is " + record.Status})
+			return
+		}
+
+		settings, err := loadStudioSettings(db)
+		if err != nil {
+			log.Error().Err(err).Msg("Error finding studio settings")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load studio settings"})
+			return
+		}
+
+		kids := make([]string, len(record.Details.Kids))
+		for i, kid := range record.Details.Kids {
+			kids[i] = fmt.Sprintf("%s (%d)", kid.Name, kid.Age)
+		}
+		item := receipts.Line{
+			Title: record.Details.ActivityName,
+			Details: []string{
+				"Дата: " + record.Details.Date.Format(receiptDateLayout),
+				"Діти: " + strings.Join(kids, ", "),
+			},
+			Amount: int(record.TotalPrice),
+		}
+
+		doc := receipts.Document{
+			Title:    "Підтвердження запису",
+			IssuedAt: time.Now().UTC(),
+			Studio:   studioDetails(settings),
+			Customer: record.ParentName,
+			Phone:    record.PhoneNumber,
+			Total:    record.TotalPrice,
+		}
+
+		if record.SubscriptionID != nil {
+			item.Amount = 0
+			doc.Lines = []receipts.Line{item}
+			doc.Total = 0
+			doc.Notes = []string{fmt.Sprintf("Заняття за абонементом № %d", *record.SubscriptionID)}
+			sendReceipt(c, doc, fmt.Sprintf("booking-%d.pdf", record.ID))
+			return
+		}
+
+		if breakdown := record.PriceBreakdown; breakdown != nil {
+			item.Amount = int(breakdown.Base)
+			doc.Lines = append(doc.Lines, item)
+			for _, adj := range breakdown.Adjustments {
+				doc.Lines = append(doc.Lines, receipts.Line{Title: adj.Label, Amount: adj.Amount})
+			}
+		} else {
+			doc.Lines = append(doc.Lines, item)
+		}
+
+		if record.Status == models.RecordStatusPending {
+			doc.Notes = []string{"Запис очікує підтвердження студією"}
+			sendReceipt(c, doc, fmt.Sprintf("booking-%d.pdf", record.ID))
+			return
+		}
+
+		receipt, err := issueReceiptTx(db, &record.ID, nil, record.TotalPrice)
+		if err != nil {
+			log.Error().Err(err).Uint("record_id", record.ID).Msg("Failed to issue receipt")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to issue receipt"})
+			return
+		}
+
+		doc.Title = "Квитанція"
+		doc.Number = receipt.Number
+		doc.IssuedAt = receipt.IssuedAt
+		doc.Total = receipt.Total
+		sendReceipt(c, doc, fmt.Sprintf("receipt-%s.pdf", receipt.Number))
+	}
+}
+
+// Квитанция об оплате абонемента
+func GetSubscriptionReceipt() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var sub models.Subscription
+		db := database.GetGormDB()
+
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+			return
+		}
+
+		userID, isOwner, ok := receiptViewer(c, db)
+		if !ok {
+			return
+		}
+
+		query := db.Preload("User").
+			Preload("SubKids").
+			Preload("SubscriptionType").
+			Preload("SubscriptionType.Activity")
+		if !isOwner {
+			query = query.Where("user_id = ?", userID)
+		}
+		if err := query.First(&sub, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Subscription not found"})
+				return
+			}
+			log.Error().Err(err).Int("id", id).Msg("Failed to get subscription for receipt")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get subscription"})
+			return
+		}
+
+		settings, err := loadStudioSettings(db)
+		if err != nil {
+			log.Error().Err(err).Msg("Error finding studio settings")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load studio settings"})
+			return
+		}
+
+		var promoDiscount uint
+		if err := db.Model(&models.PromoRedemption{}).
+			Where("subscription_id = ?", sub.ID).
+			Select("COALESCE(SUM(discount), 0)").
+			Scan(&promoDiscount).Error; err != nil {
+			log.Error().Err(err).Uint("subscription_id", sub.ID).Msg("Failed to load promo discount")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to issue receipt"})
+			return
+		}
+
+		receipt, err := issueReceiptTx(db, nil, &sub.ID, sub.PricePaid)
+		if err != nil {
+			log.Error().Err(err).Uint("subscription_id", sub.ID).Msg("Failed to issue receipt")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to issue receipt"})
+			return
+		}
+
+		kids := make([]string, len(sub.SubKids))
+		for i, kid := range sub.SubKids {
+			kids[i] = fmt.Sprintf("%s (%d)", kid.Name, kid.Age)
+		}
+		lines := []receipts.Line{{
+			Title: fmt.Sprintf("Абонемент «%s»", sub.SubscriptionType.Name),
+			Details: []string{
+				"Заняття: " + sub.SubscriptionType.Activity.Name,
+				"Діти: " + strings.Join(kids, ", "),
+				fmt.Sprintf("Діє з %s по %s", sub.StartDate.Format("02.01.2006"), sub.EndDate.Format("02.01.2006")),
+				fmt.Sprintf("Візитів: %d", sub.VisitsTotal),
+			},
+			Amount: int(sub.PricePaid + promoDiscount),
+		}}
+		if promoDiscount > 0 {
+			lines = append(lines, receipts.Line{Title: "Знижка за промокодом", Amount: -int(promoDiscount)})
+		}
+
+		sendReceipt(c, receipts.Document{
+			Title:    "Квитанція",
+			Number:   receipt.Number,
+			IssuedAt: receipt.IssuedAt,
+			Studio:   studioDetails(settings),
+			Customer: strings.TrimSpace(sub.User.Name + " " + sub.User.Surname),
+			Phone:    sub.User.PhoneNumber,
+			Lines:    lines,
+			Total:    receipt.Total,
+		}, fmt.Sprintf("receipt-%s.pdf", receipt.Number))
+	}
+}
@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"art/utils"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	recordKindSubscription = "subscription"
+	recordKindOneOff       = "one_off"
+
+	recordSortDate    = "date"
+	recordSortCreated = "created_at"
+)
+
+// Фильтры и сортировка списка записей владельца. Все поля входят в ключ кэша
+type recordFilter struct {
+	Date       string // Один день занятия, YYYY-MM-DD
+	From       string // Период дат занятия, YYYY-MM-DD включительно
+	To         string
+	ActivityID uint
+	SlotID     uint
+	Phone      string // Часть номера телефона
+	ParentName string // Часть имени родителя без учёта регистра
+	Kind       string // subscription — записи по абонементу, one_off — разовые
+	Statuses   []string
+	Sort       string
+	Desc       bool
+
+	from, to time.Time
+}
+
+// parseRecordFilter разбирает query-параметры списка записей и сам отвечает 400 на ошибку
+func parseRecordFilter(c *gin.Context) (recordFilter, bool) {
+	f := recordFilter{
+		Date:       c.Query("date"),
+		From:       c.Query("from"),
+		To:         c.Query("to"),
+		Phone:      strings.TrimSpace(c.Query("phone")),
+		ParentName: strings.TrimSpace(c.Query("parent_name")),
+		Kind:       c.Query("kind"),
+		Sort:       c.DefaultQuery("sort", recordSortCreated),
+	}
+
+	if f.Date != "" {
+		if _, err := time.Parse("2006-01-02", f.Date); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid date"})
+			return f, false
+		}
+	}
+
+	var err error
+	if f.from, f.to, err = utils.ParseDateRange(f.From, f.To); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return f, false
+	}
+
+	for param, target := range map[string]*uint{"activity_id": &f.ActivityID, "slot_id": &f.SlotID} {
+		value := c.Query(param)
+		if value == "" {
+			continue
+		}
+		id, err := strconv.ParseUint(value, 10, 0)
+		if err != nil || id < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + param})
+			return f, false
+		}
+		*target = uint(id)
+	}
+
+	if f.Kind != "" && f.Kind != recordKindSubscription && f.Kind != recordKindOneOff {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "kind must be subscription or one_off"})
+		return f, false
+	}
+
+	var ok bool
+	if f.Statuses, ok = parseStatusFilter(c); !ok {
+		return f, false
+	}
+
+	if f.Sort != recordSortDate && f.Sort != recordSortCreated {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "sort must be date or created_at"})
+		return f, false
+	}
+	switch c.DefaultQuery("order", "desc") {
+	case "desc":
+		f.Desc = true
+	case "asc":
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "order must be asc or desc"})
+		return f, false
+	}
+
+	return f, true
+}
+
+// apply добавляет к запросу условия фильтра. Дата занятия хранится в details->>'date' (jsonb)
+func (f recordFilter) apply(query *gorm.DB) *gorm.DB {
+	if f.Date != "" {
+		query = query.Where("DATE(details->>'date') = ?", f.Date)
+	}
+	if !f.from.IsZero() {
+		query = query.Where("(details->>'date')::timestamptz >= ?", f.from)
+	}
+	if !f.to.IsZero() {
+		query = query.Where("(details->>'date')::timestamptz < ?", f.to)
+	}
+	if f.ActivityID != 0 {
+		query = query.Where("(details->>'activity_id')::int = ?", f.ActivityID)
+	}
+	if f.SlotID != 0 {
+		query = query.Where("slot_id = ?", f.SlotID)
+	}
+	if f.Phone != "" {
+		query = query.Where("phone_number LIKE ?", "%"+escapeLike(f.Phone)+"%")
+	}
+	if f.ParentName != "" {
+		query = query.Where("parent_name ILIKE ?", "%"+escapeLike(f.ParentName)+"%")
+	}
+	switch f.Kind {
+	case recordKindSubscription:
+		query = query.Where("subscription_id IS NOT NULL")
+	case recordKindOneOff:
+		query = query.Where("subscription_id IS NULL")
+	}
+	if len(f.Statuses) > 0 {
+		query = query.Where("status IN ?", f.Statuses)
+	}
+	return query
+}
+
+// sortColumn — SQL-выражение, по которому сортируется список
+func (f recordFilter) sortColumn() string {
+	if f.Sort == recordSortDate {
+		return "(details->>'date')::timestamptz"
+	}
+	return "created_at"
+}
+
+// orderBy — сортировка списка, id добавлен для стабильной пагинации
+func (f recordFilter) orderBy() string {
+	direction := "ASC"
+	if f.Desc {
+		direction = "DESC"
+	}
+	return fmt.Sprintf("%s %s, id %s", f.sortColumn(), direction, direction)
+}
+
+// cacheKey однозначно описывает набор фильтров, чтобы разные выборки не делили кэш
+func (f recordFilter) cacheKey() string {
+	return fmt.Sprintf("date:%s:from:%s:to:%s:activity:%d:slot:%d:phone:%s:parent:%s:kind:%s:status:%s:sort:%s:desc:%t",
+		f.Date, f.From, f.To, f.ActivityID, f.SlotID, f.Phone, strings.ToLower(f.ParentName), f.Kind,
+		strings.Join(f.Statuses, ","), f.Sort, f.Desc)
+}
+
+// escapeLike экранирует спецсимволы LIKE, чтобы поиск шёл по подстроке как есть
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -45,40 +46,29 @@ func MakeRecord() gin.HandlerFunc {
 
 		log.Info().Any("request", req).Msg("Got request for record")
 
-		// Рассчитываем общую сумму
-		var totalPrice uint
-		var recordDetail models.RecordDetail
-		var activity models.Activity
-		if err := db.First(&activity, req.ActivityID).Error; err != nil {
-			if err == gorm.ErrRecordNotFound {
-				log.Error().Err(err).Msg("Activity not found")
-				c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("Activity with ID %d not found", req.ActivityID)})
-				return
-			}
-			log.Error().Err(err).Msg("Error of database")
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error: " + err.Error()})
+		var user models.User
+		if err := db.Where("phone_number = ?", phone_number).First(&user).Error; err != nil {
+			log.Error().Err(err).Msg("Error to find user by phone number")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user"})
 			return
 		}
 
-		var slot models.ActivitySlot
-		if err := db.First(&slot, req.SlotID).Error; err != nil {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Слот не найден"})
+		if blocked := checkBookingBlock(c, db, user.ID); blocked {
 			return
 		}
-		if slot.Booked >= slot.Capacity {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Места закончились"})
-			return
-		}
-		if slot.StartTime.IsZero() {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Дата занятия обязательна и должна быть в будущем"})
-			return
-		}
-		if slot.StartTime.Before(time.Now()) {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Дата занятия должна быть в будущем"})
+
+		kids, err := resolveBookingKids(db, user.ID, req.Kids, req.UserKidIDs, req.NumberOfKids)
+		if err != nil {
+			respondKidsError(c, err)
 			return
 		}
+		req.Kids = kids
+		req.NumberOfKids = uint(len(kids))
 
-		slot.Booked += int(req.NumberOfKids)
+		phoneNumber, ok := phone_number.(string)
+		if !ok {
+			log.Error().Msg("Failed to bring phone_number to string")
+		}
 
 		tx := db.Begin()
 		defer func() {
@@ -87,93 +77,66 @@ func MakeRecord() gin.HandlerFunc {
 			}
 		}()
 
-		if err := tx.Save(&slot).Error; err != nil {
+		record, slot, err := createSlotRecord(tx, slotBooking{
+			UserID:      &user.ID,
+			PhoneNumber: phoneNumber,
+			ParentName:  user.Name + " " + user.Surname,
+			ActivityID:  req.ActivityID,
+			SlotID:      req.SlotID,
+			Kids:        req.Kids,
+			PromoCode:   req.PromoCode,
+		})
+		if err != nil {
 			tx.Rollback()
-			log.Error().Err(err).Msg("Error saving activity slots")
-			c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("Failed to save slot: %v", slot)})
+			respondBookingError(c, slot, err)
 			return
 		}
 
-		totalPrice += activity.Price * req.NumberOfKids
-		recordDetail = models.RecordDetail{
-			ActivityID:   req.ActivityID,
-			ActivityName: activity.Name,
-			NumberOfKids: req.NumberOfKids,
-			Kids:         req.Kids,
-			Date:         slot.StartTime.UTC(),
-		}
-
-		var user models.User
-		if err := tx.Where("phone_number = ?", phone_number).Find(&user).Error; err != nil {
-			tx.Rollback()
-			log.Error().Err(err).Msg("Error to find user by phone number")
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user"})
+		if err := tx.Commit().Error; err != nil {
+			log.Error().Err(err).Msg("Commit failed for create record")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Transaction failed"})
 			return
 		}
 
-		phoneNumber, ok := phone_number.(string)
-		if !ok {
-			log.Error().Msg("Failed to bring phone_number to string")
+		// Очиcтка кэша
+		if redisClient != nil {
+			utils.InvalidateCache(c, "/records", "records:all:*", fmt.Sprintf("client:records:%s:*", phoneNumber))
 		}
 
-		var record models.Record // Создаем заказ
-
-		record.UserID = user.ID
-		record.PhoneNumber = phoneNumber
-		record.ParentName = user.Name + " " + user.Surname
-		record.TotalPrice = totalPrice
-		record.Details = recordDetail
-		record.SlotID = req.SlotID
-		record.CreatedAt = time.Now().UTC()
-
-		log.Info().Any("record", record).Msg("Creating record") // Логируем заказ перед сохранением
-
-		for _, kid := range req.Kids {
-			var existingRecord models.Record
-			if err := tx.Where("slot_id = ? AND details->'kids'->0->>'name' = ? AND details->'kids'->0->>'age' = ? AND details->'kids'->0->>'gender' = ?",
-				req.SlotID,
-				kid.Name,
-				strconv.Itoa(kid.Age),
-				kid.Gender,
-			).First(&existingRecord).Error; err == nil {
-				tx.Rollback() // Дубль найден
-				log.Warn().Msgf("Find duplicate of record for kid: %s", kid.Name)
-				c.JSON(http.StatusConflict, gin.H{
-					"error":    "Record for this kid on this slot already exist",
-					"kid_name": kid.Name,
-				})
-				return
-			} else if !errors.Is(err, gorm.ErrRecordNotFound) {
-				// Другая ошибка БД
-				tx.Rollback()
-				log.Error().Err(err).Msg("Ошибка проверки дубля записи")
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка сервера при проверке дубля"})
-				return
-			}
-		}
+		c.JSON(http.StatusCreated, recordCreatedResponse(record))
+	}
+}
 
-		if err := tx.Create(&record).Error; err != nil {
-			tx.Rollback()
-			log.Error().Err(err).Msg("Database error")
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error to create record: " + err.Error()})
-			return
+// findDuplicateKid ищет ребёнка из списка, который уже записан на слот
+func findDuplicateKid(tx *gorm.DB, slotID uint, kids []models.Kid) (*models.Kid, error) {
+	for _, kid := range kids {
+		query := tx.Model(&models.RecordKid{}).Where("slot_id = ? AND active", slotID)
+		if kid.UserKidID != nil {
+			query = query.Where("(user_kid_id = ? OR (LOWER(name) = LOWER(?) AND age = ? AND gender = ?))",
+				*kid.UserKidID, kid.Name, kid.Age, kid.Gender)
+		} else {
+			query = query.Where("LOWER(name) = LOWER(?) AND age = ? AND gender = ?", kid.Name, kid.Age, kid.Gender)
 		}
-		tx.Commit()
 
-		// Очиcтка кэша
-		if redisClient != nil {
-			utils.InvalidateCache(c, "/records", "records:all:*", fmt.Sprintf("client:records:%s:*", phoneNumber))
+		var count int64
+		if err := query.Count(&count).Error; err != nil {
+			return nil, err
+		}
+		if count > 0 {
+			return &kid, nil
 		}
-
-		c.JSON(http.StatusCreated, gin.H{
-			"record_id":    record.ID,
-			"created_at":   record.CreatedAt.Format(time.RFC3339),
-			"Details":      record.Details,
-			"total_price":  record.TotalPrice,
-			"phone_number": record.PhoneNumber,
-			"parent_name":  record.ParentName,
-		})
 	}
+	return nil, nil
+}
+
+// Postgres: unique_violation
+func isUniqueViolation(err error) bool {
+	return err != nil && (errors.Is(err, gorm.ErrDuplicatedKey) || strings.Contains(err.Error(), "SQLSTATE 23505"))
+}
+
+// isExclusionViolation — нарушено ограничение EXCLUDE, например пересеклись периоды шаблонов
+func isExclusionViolation(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "SQLSTATE 23P01")
 }
 
 func GetMyRecords() gin.HandlerFunc {
@@ -210,8 +173,13 @@ func GetMyRecords() gin.HandlerFunc {
 
 		log.Info().Any("phone_number", phone_number).Msg("Got phone number")
 
+		statuses, ok := parseStatusFilter(c)
+		if !ok {
+			return
+		}
+
 		phoneStr := phone_number.(string)
-		cacheKey := fmt.Sprintf("client:records:%s:page:%d:size:%d", phoneStr, page, size)
+		cacheKey := fmt.Sprintf("client:records:%s:page:%d:size:%d:status:%s", phoneStr, page, size, strings.Join(statuses, ","))
 
 		cached, err := redisClient.Get(c, cacheKey).Result()
 		if err == nil {
@@ -234,8 +202,13 @@ func GetMyRecords() gin.HandlerFunc {
 			return
 		}
 
+		query := db.Model(&models.Record{}).Where("phone_number = ?", phone_number)
+		if len(statuses) > 0 {
+			query = query.Where("status IN ?", statuses)
+		}
+
 		var totalCount int64
-		if err := db.Model(&models.Record{}).Where("phone_number = ?", phone_number).Count(&totalCount).Error; err != nil {
+		if err := query.Count(&totalCount).Error; err != nil {
 			log.Error().Err(err).Msg("Failed to count recordsfor user")
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to count records"})
 			return
@@ -243,10 +216,10 @@ func GetMyRecords() gin.HandlerFunc {
 
 		// Выборка заказов с пагинацией
 		var records []models.Record
-		if err := db.
+		if err := query.
+			Preload("Kids").
 			Limit(size).
-			Offset((page-1)*size).
-			Where("phone_number = ?", phone_number).
+			Offset((page - 1) * size).
 			Find(&records).Error; err != nil {
 			log.Error().Err(err).Msg("Failed to find order by phone number")
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch records"})
@@ -315,9 +288,12 @@ func GetAllRecords() gin.HandlerFunc {
 			return
 		}
 
-		dateFilter := c.Query("date")
+		filter, ok := parseRecordFilter(c)
+		if !ok {
+			return
+		}
 
-		cacheKey := fmt.Sprintf("records:all:page:%d:size:%d:date:%s", page, size, dateFilter)
+		cacheKey := fmt.Sprintf("records:all:page:%d:size:%d:%s", page, size, filter.cacheKey())
 
 		cached, err := redisClient.Get(c, cacheKey).Result()
 		if err == nil {
@@ -333,13 +309,7 @@ func GetAllRecords() gin.HandlerFunc {
 			log.Error().Err(err).Msg("Failed to hit cache for all records")
 		}
 
-		query := db.Model(&models.Record{})
-
-		if dateFilter != "" {
-			// Фильтр по полю Details->date (jsonb)
-			// PostgreSQL позволяет обращаться к jsonb-полям через ->
-			query = query.Where("DATE(details->>'date') = ?", dateFilter)
-		}
+		query := filter.apply(db.Model(&models.Record{}))
 
 		// Подсчёт общего количества заказов
 		var totalCount int64
@@ -352,7 +322,8 @@ func GetAllRecords() gin.HandlerFunc {
 		// Выборка заказов с пагинацией
 		var records []models.Record
 		if err := query.
-			Order("created_at DESC").
+			Preload("Kids").
+			Order(filter.orderBy()).
 			Offset((page - 1) * size).
 			Limit(size).
 			Find(&records).Error; err != nil {
@@ -410,13 +381,14 @@ func GetRecordByID() gin.HandlerFunc {
 			return
 		}
 
-		if err := db.First(&order, id).Error; err != nil {
+		if err := db.Preload("Kids").First(&order, id).Error; err != nil {
 			log.Error().Err(err).Msg("Failed to get order by ID")
 			ctx.JSON(http.StatusInternalServerError, gin.H{
 				"error": "Error to find orders",
 			})
 			return
 		}
+		order.FillDetailKids()
 
 		ctx.JSON(http.StatusOK, order)
 	}
@@ -442,7 +414,13 @@ func DeleteRecordByID() gin.HandlerFunc {
 
 		log.Info().Int("id", id).Msg("Attempting to delete record")
 
-		if err := db.First(&record, id).Error; err != nil {
+		changedBy := currentUserID(c, db)
+
+		tx := db.Begin()
+
+		// Запись блокируется до коммита, чтобы параллельная отмена не вернула места и визит второй раз
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&record, id).Error; err != nil {
+			tx.Rollback()
 			if errors.Is(err, gorm.ErrRecordNotFound) {
 				log.Warn().Int("id", id).Msg("Record not found")
 				c.JSON(http.StatusNotFound, gin.H{"error": "Record not found"})
@@ -453,10 +431,106 @@ func DeleteRecordByID() gin.HandlerFunc {
 			return
 		}
 
+		if !models.IsActiveRecordStatus(record.Status) {
+			tx.Rollback()
+			c.JSON(http.StatusConflict, gin.H{"error": "Record is already " + record.Status})
+			return
+		}
+
+		if err := cancelRecord(tx, &record, models.RecordStatusCancelledByStudio, c.Query("reason"), changedBy, true); err != nil {
+			tx.Rollback()
+			log.Error().Err(err).Int("id", id).Msg("Failed to delete record")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete record"})
+			return
+		}
+
+		promoted, err := promoteWaitlist(tx, record.SlotID)
+		if err != nil {
+			tx.Rollback()
+			log.Error().Err(err).Uint("slot_id", record.SlotID).Msg("Failed to promote waitlist")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to promote waitlist"})
+			return
+		}
+
+		if err := tx.Commit().Error; err != nil {
+			log.Error().Err(err).Msg("Commit failed for delete record")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Transaction failed"})
+			return
+		}
+
+		log.Info().Int("id", id).Str("phone", record.PhoneNumber).Msg("Record cancelled by studio")
+
+		if redisClient != nil {
+			invalidateRecordCache(c, &record)
+			invalidatePromotedCache(c, promoted)
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// Отмена записи самим клиентом, не позже чем за CancelWindowHours до начала занятия
+func CancelMyRecord() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var record models.Record
+		var user models.User
+		db := database.GetGormDB()
+		redisClient, err := database.GetRedis()
+		if err != nil {
+			log.Error().Err(err).Msg("Error getting redis")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get redis"})
+			return
+		}
+
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to parse ID")
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+			return
+		}
+
+		phoneNumber, ok := c.Get("phone_number")
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		if err := db.Where("phone_number = ?", phoneNumber).First(&user).Error; err != nil {
+			log.Error().Err(err).Msgf("Error finding user with phone number: %v", phoneNumber)
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+
+		settings, err := loadStudioSettings(db)
+		if err != nil {
+			log.Error().Err(err).Msg("Error loading studio settings")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load studio settings"})
+			return
+		}
+
 		tx := db.Begin()
 
-		var slot models.ActivitySlot
+		// Чужие записи для клиента не существуют. Блокировка не даёт отменить запись дважды параллельными запросами
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ? AND user_id = ?", id, user.ID).First(&record).Error; err != nil {
+			tx.Rollback()
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				log.Warn().Int("id", id).Uint("user_id", user.ID).Msg("Record not found for user")
+				c.JSON(http.StatusNotFound, gin.H{"error": "Record not found"})
+				return
+			}
+			log.Error().Err(err).Msg("Failed to get record by ID")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error to find record"})
+			return
+		}
+
+		if !models.IsActiveRecordStatus(record.Status) {
+			tx.Rollback()
+			c.JSON(http.StatusConflict, gin.H{"error": "Record is already " + record.Status})
+			return
+		}
 
+		startTime := record.Details.Date
+		var slot models.ActivitySlot
 		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&slot, record.SlotID).Error; err != nil {
 			if !errors.Is(err, gorm.ErrRecordNotFound) {
 				tx.Rollback()
@@ -464,115 +538,433 @@ func DeleteRecordByID() gin.HandlerFunc {
 				c.JSON(http.StatusInternalServerError, gin.H{"error": "slot lookup failed"})
 				return
 			}
-			log.Warn().
-				Uint("slot_id", record.SlotID).
-				Msg("slot missing, deleting record without restoring places")
 		} else {
-			if record.Details.NumberOfKids > uint(slot.Booked) {
-				slot.Booked = 0
-			} else {
-				slot.Booked -= int(record.Details.NumberOfKids)
-			}
+			startTime = slot.StartTime
+		}
 
-			if err := tx.Save(&slot).Error; err != nil {
+		now := time.Now().UTC()
+		deadline := cancelDeadline(startTime, settings)
+		lateCancel := now.After(deadline)
+		// Поздняя отмена возможна только до начала занятия и только если студия считает её неявкой
+		if lateCancel && (!settings.LateCancelAsNoShow || !now.Before(startTime)) {
+			tx.Rollback()
+			log.Info().Int("id", id).Time("deadline", deadline).Msg("Cancellation window has passed")
+			c.JSON(http.StatusConflict, gin.H{
+				"error":           fmt.Sprintf("Скасувати запис можна не пізніше ніж за %d год. до початку заняття", settings.CancelWindowHours),
+				"reason":          "cancellation_window_passed",
+				"cancel_deadline": deadline.Format(time.RFC3339),
+			})
+			return
+		}
+
+		burnVisit := lateCancel && settings.NoShowBurnsVisit
+		if burnVisit {
+			if err := chargeVisit(tx, &record); err != nil {
 				tx.Rollback()
-				log.Error().Err(err).Msg("Failed to restore slot places")
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to restore places"})
+				log.Error().Err(err).Int("id", id).Msg("Failed to charge visit for late cancellation")
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel record"})
 				return
 			}
 		}
 
-		var subType models.SubscriptionType
-		if err := tx.Where("activity_id = ?", slot.ActivityID).First(&subType).Error; err != nil {
-			if !errors.Is(err, gorm.ErrRecordNotFound) {
+		if err := cancelRecord(tx, &record, models.RecordStatusCancelledByClient, "", &user.ID, !burnVisit); err != nil {
+			tx.Rollback()
+			log.Error().Err(err).Int("id", id).Msg("Failed to cancel record")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel record"})
+			return
+		}
+
+		if lateCancel {
+			if err := recordNoShow(tx, &record, models.NoShowReasonLateCancel, now); err != nil {
 				tx.Rollback()
-				log.Error().Err(err).Msg("Error finding subscription type")
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to find subscription type"})
+				log.Error().Err(err).Int("id", id).Msg("Failed to record late cancellation")
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel record"})
 				return
 			}
-			log.Warn().
-				Uint("activity_id", slot.ActivityID).
-				Msg("sub type missing, deleting record without restoring sub visits")
 		}
 
-		var subscription models.Subscription
-		if err := tx.Joins("JOIN subscription_kids sk ON sk.subscription_id = subscriptions.id").
-			Joins("JOIN sub_kids kids ON kids.id = sk.sub_kid_id").
-			Where("kids.id = ?", record.SubKidID).
-			Preload("SubKids").First(&subscription).First(&subscription).Error; err != nil {
-			if !errors.Is(err, gorm.ErrRecordNotFound) {
+		promoted, err := promoteWaitlist(tx, record.SlotID)
+		if err != nil {
+			tx.Rollback()
+			log.Error().Err(err).Uint("slot_id", record.SlotID).Msg("Failed to promote waitlist")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to promote waitlist"})
+			return
+		}
+
+		if err := tx.Commit().Error; err != nil {
+			log.Error().Err(err).Msg("Commit failed for cancel record")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Transaction failed"})
+			return
+		}
+
+		log.Info().Int("id", id).Str("phone", record.PhoneNumber).Bool("late", lateCancel).Msg("Record cancelled by client")
+
+		if redisClient != nil {
+			invalidateRecordCache(c, &record)
+			invalidatePromotedCache(c, promoted)
+		}
+
+		if lateCancel {
+			c.JSON(http.StatusOK, gin.H{
+				"message": "Запис скасовано пізніше дозволеного часу, це зараховано як неявку",
+				"reason":  models.NoShowReasonLateCancel,
+			})
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// Перенос записи на другой слот. Владелец переносит любую запись, клиент — только свою и в пределах окна отмены
+func RescheduleRecord() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req models.RescheduleRequest
+		var record models.Record
+		db := database.GetGormDB()
+		redisClient, err := database.GetRedis()
+		if err != nil {
+			log.Error().Err(err).Msg("Error getting redis")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get redis"})
+			return
+		}
+
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to parse ID")
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+			return
+		}
+
+		if err := c.ShouldBindJSON(&req); err != nil {
+			log.Error().Err(err).Msg("Error binding json")
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+			return
+		}
+
+		isOwner := c.GetString("role") == "owner"
+
+		var userID uint
+		if !isOwner {
+			var user models.User
+			phoneNumber, _ := c.Get("phone_number")
+			if err := db.Where("phone_number = ?", phoneNumber).First(&user).Error; err != nil {
+				log.Error().Err(err).Msgf("Error finding user with phone number: %v", phoneNumber)
+				c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+				return
+			}
+			userID = user.ID
+		}
+
+		settings, err := loadStudioSettings(db)
+		if err != nil {
+			log.Error().Err(err).Msg("Error loading studio settings")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load studio settings"})
+			return
+		}
+
+		tx := db.Begin()
+		defer func() {
+			if r := recover(); r != nil {
 				tx.Rollback()
-				log.Error().Err(err).Msg("Error finding subscription")
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to find subscription"})
+			}
+		}()
+
+		// Запись блокируется раньше слотов, в том же порядке, что и при отмене
+		query := tx.Clauses(clause.Locking{Strength: "UPDATE"})
+		if !isOwner {
+			query = query.Where("user_id = ?", userID)
+		}
+		if err := query.First(&record, id).Error; err != nil {
+			tx.Rollback()
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				log.Warn().Int("id", id).Msg("Record not found")
+				c.JSON(http.StatusNotFound, gin.H{"error": "Record not found"})
 				return
 			}
-			log.Warn().
-				Uint("subscription", subscription.ID).
-				Msg("subscription missing, deleting record without restoring sub visits")
-		} else {
+			log.Error().Err(err).Msg("Failed to get record by ID")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error to find record"})
+			return
+		}
+		if err := tx.Where("record_id = ?", record.ID).Order("id").Find(&record.Kids).Error; err != nil {
+			tx.Rollback()
+			log.Error().Err(err).Int("id", id).Msg("Failed to get record kids")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error to find record"})
+			return
+		}
+		record.FillDetailKids()
 
-			if subscription.VisitsUsed > 0 {
-				subscription.VisitsUsed -= 1
-			} else {
-				log.Warn().Uint("subscription_id", subscription.ID).Msg("VisitsUsed already 0, skipping decrement")
+		if !models.IsActiveRecordStatus(record.Status) {
+			tx.Rollback()
+			c.JSON(http.StatusConflict, gin.H{"error": "Record is already " + record.Status})
+			return
+		}
+
+		if req.SlotID == record.SlotID {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Record is already on this slot"})
+			return
+		}
+
+		oldSlotID := record.SlotID
+		oldActivityID := record.Details.ActivityID
+		seats := int(record.Details.NumberOfKids)
+
+		if err := lockSlots(tx, oldSlotID, req.SlotID); err != nil {
+			tx.Rollback()
+			log.Error().Err(err).Msg("Failed to lock slots for reschedule")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to lock slots"})
+			return
+		}
+
+		var oldSlot models.ActivitySlot
+		if err := tx.First(&oldSlot, oldSlotID).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			tx.Rollback()
+			log.Error().Err(err).Uint("slot_id", oldSlotID).Msg("Failed to get old slot")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get slot"})
+			return
+		}
+
+		if !isOwner {
+			startTime := record.Details.Date
+			if oldSlot.ID != 0 {
+				startTime = oldSlot.StartTime
 			}
 
-			if err := tx.Save(&subscription).Error; err != nil {
+			deadline := cancelDeadline(startTime, settings)
+			if time.Now().UTC().After(deadline) {
 				tx.Rollback()
-				log.Error().Err(err).Msg("Failed to restore subscription visits")
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to restore subscription visits"})
+				log.Info().Int("id", id).Time("deadline", deadline).Msg("Reschedule window has passed")
+				c.JSON(http.StatusConflict, gin.H{
+					"error":           fmt.Sprintf("Перенести запис можна не пізніше ніж за %d год. до початку заняття", settings.CancelWindowHours),
+					"reason":          "cancellation_window_passed",
+					"cancel_deadline": deadline.Format(time.RFC3339),
+				})
 				return
 			}
 		}
 
-		if err := tx.Delete(&record, id).Error; err != nil {
+		newSlot, err := reserveSeats(tx, req.SlotID, seats)
+		if err != nil {
 			tx.Rollback()
-			log.Error().Err(err).Int("id", id).Msg("Failed to delete record")
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete record"})
+			switch {
+			case errors.Is(err, errSlotNotFound):
+				c.JSON(http.StatusNotFound, gin.H{"error": "Слот не найден"})
+			case errors.Is(err, errSlotInPast):
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Дата занятия должна быть в будущем"})
+			case errors.Is(err, errSlotFull):
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error":       "Места закончились",
+					"free_places": newSlot.FreePlaces(),
+				})
+			default:
+				log.Error().Err(err).Msg("Error reserving slot places")
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reserve slot places"})
+			}
+			return
+		}
+
+		activity, err := compatibleSlot(tx, &record, oldSlot, newSlot)
+		if err != nil {
+			tx.Rollback()
+			if errors.Is(err, errIncompatibleActivity) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Запис можна перенести лише на заняття з тією ж ціною, абонементні — лише в межах свого заняття"})
+				return
+			}
+			log.Error().Err(err).Msg("Error checking activity for reschedule")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check activity"})
+			return
+		}
+		record.Details.ActivityID = activity.ID
+		record.Details.ActivityName = activity.Name
+
+		ages, err := slotAgeRange(tx, activity, newSlot)
+		if err != nil {
+			tx.Rollback()
+			log.Error().Err(err).Uint("slot_id", newSlot.ID).Msg("Error loading slot age range")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check kids age"})
+			return
+		}
+		if kid := kidOutsideAgeRange(record.Details.Kids, ages); kid != nil {
+			tx.Rollback()
+			c.JSON(ageErrorBody(ages, kid))
+			return
+		}
+
+		duplicate, err := findDuplicateKid(tx, newSlot.ID, record.Details.Kids)
+		if err != nil {
+			tx.Rollback()
+			log.Error().Err(err).Msg("Ошибка проверки дубля записи")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка сервера при проверке дубля"})
+			return
+		}
+		if duplicate != nil {
+			tx.Rollback()
+			c.JSON(http.StatusConflict, gin.H{
+				"error":    "Record for this kid on this slot already exist",
+				"kid_name": duplicate.Name,
+			})
+			return
+		}
+
+		if err := releaseSeats(tx, oldSlotID, seats); err != nil {
+			tx.Rollback()
+			log.Error().Err(err).Uint("slot_id", oldSlotID).Msg("Failed to release old slot")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to release old slot"})
+			return
+		}
+
+		record.SlotID = newSlot.ID
+		record.Details.Date = newSlot.StartTime.UTC()
+
+		if err := tx.Model(&record).Updates(map[string]interface{}{
+			"slot_id": record.SlotID,
+			"details": record.Details,
+		}).Error; err != nil {
+			tx.Rollback()
+			log.Error().Err(err).Int("id", id).Msg("Failed to update record")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update record"})
+			return
+		}
+
+		if err := tx.Model(&models.RecordKid{}).
+			Where("record_id = ?", record.ID).
+			UpdateColumn("slot_id", record.SlotID).Error; err != nil {
+			tx.Rollback()
+			if isUniqueViolation(err) {
+				c.JSON(http.StatusConflict, gin.H{"error": "Record for this kid on this slot already exist"})
+				return
+			}
+			log.Error().Err(err).Int("id", id).Msg("Failed to move record kids")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update record"})
+			return
+		}
+
+		promoted, err := promoteWaitlist(tx, oldSlotID)
+		if err != nil {
+			tx.Rollback()
+			log.Error().Err(err).Uint("slot_id", oldSlotID).Msg("Failed to promote waitlist")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to promote waitlist"})
 			return
 		}
 
 		if err := tx.Commit().Error; err != nil {
-			log.Error().Err(err).Msg("Commit failed for delete record")
+			log.Error().Err(err).Msg("Commit failed for reschedule record")
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Transaction failed"})
 			return
 		}
 
-		log.Info().Int("id", id).Str("phone", record.PhoneNumber).Msg("Record deleted successfully")
+		log.Info().Int("id", id).Uint("from_slot", oldSlotID).Uint("to_slot", record.SlotID).Msg("Record rescheduled")
 
 		if redisClient != nil {
-			patterns := []string{
-				fmt.Sprintf("client:records:%s:*", record.PhoneNumber),
-				fmt.Sprintf("/activity/%d/slots*", record.Details.ActivityID),
-				"/subscriptions*",
-				"subscriptions:all:*",
-				"/records",
-				"records:all:*",
-			}
+			invalidateRecordCache(c, &record)
+			utils.InvalidateCache(c, fmt.Sprintf("/activity/%d/slots*", oldActivityID))
+			invalidatePromotedCache(c, promoted)
+		}
+
+		c.JSON(http.StatusOK, models.ToRecordResponse(record))
+	}
+}
+
+var errIncompatibleActivity = errors.New("activity is not compatible with record")
 
-			for _, pattern := range patterns {
-				cursor := uint64(0)
-				for {
-					keys, nextCursor, err := redisClient.Scan(c, cursor, pattern, 100).Result()
-					if err != nil {
-						log.Error().Err(err).Msg("Failed to scan records cache keys")
-						break
-					}
-					if len(keys) > 0 {
-						if err := redisClient.Del(c, keys...).Err(); err != nil {
-							log.Error().Err(err).Msg("Failed to delete records cache keys")
-						} else {
-							log.Info().Str("pattern", pattern).Int("keys_deleted", len(keys)).Msg("Cache keys deleted")
-						}
-					}
-					cursor = nextCursor
-					if cursor == 0 {
-						break
-					}
-				}
+// compatibleSlot возвращает занятие целевого слота. Абонементную запись можно перенести только в пределах
+// её занятия, разовую — только на слот с той же ценой за ребёнка, чтобы не пересчитывать оплату
+func compatibleSlot(tx *gorm.DB, record *models.Record, oldSlot, newSlot models.ActivitySlot) (models.Activity, error) {
+	var activity models.Activity
+	if err := tx.First(&activity, newSlot.ActivityID).Error; err != nil {
+		return activity, err
+	}
+
+	if record.SubscriptionID != nil {
+		if activity.ID != record.Details.ActivityID {
+			return activity, errIncompatibleActivity
+		}
+		return activity, nil
+	}
+
+	current := activity
+	if activity.ID != record.Details.ActivityID {
+		current = models.Activity{}
+		if err := tx.Select("id", "price").First(&current, record.Details.ActivityID).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return activity, err
+		}
+	}
+	if unitPrice(activity, newSlot) != unitPrice(current, oldSlot) {
+		return activity, errIncompatibleActivity
+	}
+	return activity, nil
+}
+
+// unitPrice — цена за ребёнка на слоте: переопределённая для слота или цена занятия
+func unitPrice(activity models.Activity, slot models.ActivitySlot) uint {
+	if slot.PriceOverride != nil {
+		return *slot.PriceOverride
+	}
+	return activity.Price
+}
+
+// Крайний срок, до которого клиент может сам отменить запись на занятие
+func cancelDeadline(startTime time.Time, settings models.StudioSettings) time.Time {
+	return startTime.UTC().Add(-time.Duration(settings.CancelWindowHours) * time.Hour)
+}
+
+// cancelRecord возвращает места в слот, визит в абонемент (если restoreVisit) и переводит запись в отменённый статус.
+// Работает внутри переданной транзакции
+func cancelRecord(tx *gorm.DB, record *models.Record, status, reason string, changedBy *uint, restoreVisit bool) error {
+	var slot models.ActivitySlot
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&slot, record.SlotID).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("slot lookup failed: %w", err)
+		}
+		log.Warn().
+			Uint("slot_id", record.SlotID).
+			Msg("slot missing, cancelling record without restoring places")
+	} else {
+		if record.Details.NumberOfKids > uint(slot.Booked) {
+			slot.Booked = 0
+		} else {
+			slot.Booked -= int(record.Details.NumberOfKids)
+		}
+
+		if err := tx.Save(&slot).Error; err != nil {
+			return fmt.Errorf("failed to restore slot places: %w", err)
+		}
+	}
+
+	if restoreVisit && record.SubscriptionID != nil && record.VisitCounted {
+		var subscription models.Subscription
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&subscription, *record.SubscriptionID).Error; err != nil {
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("failed to find subscription: %w", err)
 			}
+			log.Warn().
+				Uint("subscription_id", *record.SubscriptionID).
+				Msg("subscription missing, cancelling record without restoring sub visits")
+		} else if subscription.VisitsUsed > 0 {
+			if err := tx.Model(&subscription).
+				UpdateColumn("visits_used", gorm.Expr("visits_used - 1")).Error; err != nil {
+				return fmt.Errorf("failed to restore subscription visits: %w", err)
+			}
+		} else {
+			log.Warn().Uint("subscription_id", subscription.ID).Msg("VisitsUsed already 0, skipping decrement")
 		}
+	}
 
-		c.Status(http.StatusNoContent)
+	if err := releasePromo(tx, record.ID); err != nil {
+		return err
 	}
+
+	return setRecordStatus(tx, record, status, reason, changedBy)
+}
+
+func invalidateRecordCache(c *gin.Context, record *models.Record) {
+	utils.InvalidateCache(c,
+		fmt.Sprintf("client:records:%s:*", record.PhoneNumber),
+		fmt.Sprintf("/activity/%d/slots*", record.Details.ActivityID),
+		"/subscriptions*",
+		"subscriptions:all:*",
+		"/records",
+		"records:all:*",
+	)
 }
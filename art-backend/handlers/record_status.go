@@ -0,0 +1,237 @@
+package handlers
+
+import (
+	"art/database"
+	"art/models"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var errInvalidTransition = errors.New("invalid record status transition")
+
+// setRecordStatus переводит запись в новый статус и пишет историю. Места и визиты не трогает —
+// для отмены с возвратом мест используется cancelRecord
+func setRecordStatus(tx *gorm.DB, record *models.Record, to, reason string, changedBy *uint) error {
+	from := record.Status
+	if from == to {
+		return nil
+	}
+	if !models.CanTransitionRecord(from, to) {
+		return fmt.Errorf("%w: %s -> %s", errInvalidTransition, from, to)
+	}
+
+	now := time.Now().UTC()
+	if err := tx.Model(record).Updates(map[string]interface{}{
+		"status":            to,
+		"status_reason":     reason,
+		"status_changed_at": now,
+	}).Error; err != nil {
+		return fmt.Errorf("failed to update record status: %w", err)
+	}
+
+	if models.IsCancelledRecordStatus(to) { // Дети отменённой записи больше не занимают место в слоте
+		if err := tx.Model(&models.RecordKid{}).
+			Where("record_id = ?", record.ID).
+			UpdateColumn("active", false).Error; err != nil {
+			return fmt.Errorf("failed to deactivate record kids: %w", err)
+		}
+	}
+
+	change := models.RecordStatusChange{
+		RecordID:   record.ID,
+		FromStatus: from,
+		ToStatus:   to,
+		Reason:     reason,
+		ChangedBy:  changedBy,
+	}
+	if err := tx.Create(&change).Error; err != nil {
+		return fmt.Errorf("failed to save record status change: %w", err)
+	}
+
+	record.Status = to
+	record.StatusReason = reason
+	record.StatusChangedAt = &now
+	return nil
+}
+
+// currentUserID возвращает id пользователя из токена, nil — если его не удалось найти
+func currentUserID(c *gin.Context, db *gorm.DB) *uint {
+	phoneNumber, ok := c.Get("phone_number")
+	if !ok {
+		return nil
+	}
+	var user models.User
+	if err := db.Select("id").Where("phone_number = ?", phoneNumber).First(&user).Error; err != nil {
+		log.Warn().Err(err).Msgf("Error finding user with phone number: %v", phoneNumber)
+		return nil
+	}
+	return &user.ID
+}
+
+// parseStatusFilter разбирает ?status=confirmed,completed. При неизвестном статусе сам отвечает 400
+func parseStatusFilter(c *gin.Context) ([]string, bool) {
+	raw := c.Query("status")
+	if raw == "" {
+		return nil, true
+	}
+
+	var statuses []string
+	for _, s := range strings.Split(raw, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		if !models.IsRecordStatus(s) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown record status: " + s})
+			return nil, false
+		}
+		statuses = append(statuses, s)
+	}
+	return statuses, true
+}
+
+// Смена статуса записи владельцем: подтверждение, отмена, завершение или неявка
+func UpdateRecordStatus() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req models.RecordStatusRequest
+		var record models.Record
+		db := database.GetGormDB()
+
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to parse ID")
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+			return
+		}
+
+		if err := c.ShouldBindJSON(&req); err != nil {
+			log.Error().Err(err).Msg("Error binding JSON")
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		changedBy := currentUserID(c, db)
+
+		settings, err := loadStudioSettings(db)
+		if err != nil {
+			log.Error().Err(err).Msg("Error loading studio settings")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load studio settings"})
+			return
+		}
+
+		tx := db.Begin()
+
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&record, id).Error; err != nil {
+			tx.Rollback()
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Record not found"})
+				return
+			}
+			log.Error().Err(err).Int("id", id).Msg("Failed to lock record")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error to find record"})
+			return
+		}
+
+		if !models.CanTransitionRecord(record.Status, req.Status) {
+			tx.Rollback()
+			c.JSON(http.StatusConflict, gin.H{
+				"error": fmt.Sprintf("Cannot change record status from %s to %s", record.Status, req.Status),
+			})
+			return
+		}
+
+		var promoted []models.Record
+		switch {
+		case models.IsCancelledRecordStatus(req.Status):
+			err = cancelRecord(tx, &record, req.Status, req.Reason, changedBy, true)
+			if err == nil {
+				promoted, err = promoteWaitlist(tx, record.SlotID)
+			}
+		case req.Status == models.RecordStatusNoShow:
+			err = setRecordStatus(tx, &record, req.Status, req.Reason, changedBy)
+			if err == nil {
+				err = recordNoShow(tx, &record, models.NoShowReasonAbsent, record.Details.Date)
+			}
+		case req.Status == models.RecordStatusCompleted && record.Status == models.RecordStatusNoShow:
+			err = setRecordStatus(tx, &record, req.Status, req.Reason, changedBy)
+			if err == nil {
+				err = tx.Where("record_id = ? AND reason = ?", record.ID, models.NoShowReasonAbsent).
+					Delete(&models.NoShowEvent{}).Error
+			}
+		default:
+			err = setRecordStatus(tx, &record, req.Status, req.Reason, changedBy)
+		}
+		// Визит абонемента следует за итогом занятия так же, как при отметке посещаемости
+		if err == nil && settings.VisitsByAttendance &&
+			(req.Status == models.RecordStatusCompleted || req.Status == models.RecordStatusNoShow) {
+			_, err = syncRecordVisit(tx, record.ID, settings)
+		}
+		if err != nil {
+			tx.Rollback()
+			log.Error().Err(err).Int("id", id).Str("status", req.Status).Msg("Failed to change record status")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change record status"})
+			return
+		}
+
+		if err := tx.Commit().Error; err != nil {
+			log.Error().Err(err).Msg("Commit failed for record status change")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Transaction failed"})
+			return
+		}
+
+		log.Info().Int("id", id).Str("status", record.Status).Msg("Record status changed")
+
+		invalidateRecordCache(c, &record)
+		invalidatePromotedCache(c, promoted)
+
+		if err := db.Preload("Kids").First(&record, record.ID).Error; err != nil {
+			log.Error().Err(err).Int("id", id).Msg("Failed to reload record")
+		}
+		record.FillDetailKids()
+
+		c.JSON(http.StatusOK, models.ToRecordResponse(record))
+	}
+}
+
+// История смены статусов записи
+func GetRecordStatusHistory() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var changes []models.RecordStatusChange
+		db := database.GetGormDB()
+
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to parse ID")
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+			return
+		}
+
+		var count int64
+		if err := db.Model(&models.Record{}).Where("id = ?", id).Count(&count).Error; err != nil {
+			log.Error().Err(err).Int("id", id).Msg("Failed to find record")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error to find record"})
+			return
+		}
+		if count == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Record not found"})
+			return
+		}
+
+		if err := db.Where("record_id = ?", id).Order("created_at ASC, id ASC").Find(&changes).Error; err != nil {
+			log.Error().Err(err).Int("id", id).Msg("Failed to fetch record status history")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch status history"})
+			return
+		}
+
+		c.JSON(http.StatusOK, changes)
+	}
+}
@@ -0,0 +1,234 @@
+package handlers
+
+import (
+	"art/database"
+	"art/models"
+	"art/scheduler"
+	"art/utils"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	JobExtendSchedule = "extend_schedule"
+	JobAutoEnroll     = "auto_enroll"
+
+	defaultExtendCron = "0 3 * * *"  // Каждую ночь в 03:00 UTC
+	defaultEnrollCron = "30 3 * * *" // После продления расписания
+	defaultWeeksAhead = 4
+
+	jobTimeout = 30 * time.Minute
+)
+
+// Планировщик процесса, создаётся в NewScheduler при старте сервера
+var jobScheduler *scheduler.Scheduler
+
+// Кэши, которые устаревают после генерации слотов и автозаписи
+var scheduleCachePatterns = []string{
+	"activity_slots*",
+	"/records*",
+	"records:all:*",
+	"/client/records*",
+	"client:records:*",
+	"/subscriptions",
+	"subscriptions:all:*",
+	"schedule*",
+	"admin/errors*",
+}
+
+// jobCron читает cron-выражение задачи из окружения. Пустое значение — расписание по умолчанию,
+// off — задача выключена и запускается только вручную
+func jobCron(env, fallback string) (scheduler.Schedule, error) {
+	expr := strings.TrimSpace(os.Getenv(env))
+	if expr == "" {
+		expr = fallback
+	}
+	if strings.EqualFold(expr, "off") {
+		log.Info().Str("env", env).Msg("Job schedule disabled")
+		return scheduler.Never, nil
+	}
+	schedule, err := scheduler.ParseCron(expr)
+	if err != nil {
+		return schedule, fmt.Errorf("%s: %w", env, err)
+	}
+	return schedule, nil
+}
+
+// NewScheduler собирает фоновые задачи студии по настройкам из окружения:
+// SCHEDULE_EXTEND_CRON, SCHEDULE_WEEKS_AHEAD (1–12) и SCHEDULE_ENROLL_CRON
+func NewScheduler() (*scheduler.Scheduler, error) {
+	redisClient, err := database.GetRedis()
+	if err != nil {
+		return nil, err
+	}
+
+	weeks := defaultWeeksAhead
+	if value := os.Getenv("SCHEDULE_WEEKS_AHEAD"); value != "" {
+		weeks, err = strconv.Atoi(value)
+		if err != nil || weeks < 1 || weeks > 12 {
+			return nil, fmt.Errorf("SCHEDULE_WEEKS_AHEAD must be between 1 and 12, got %q", value)
+		}
+	}
+
+	extendCron, err := jobCron("SCHEDULE_EXTEND_CRON", defaultExtendCron)
+	if err != nil {
+		return nil, err
+	}
+	enrollCron, err := jobCron("SCHEDULE_ENROLL_CRON", defaultEnrollCron)
+	if err != nil {
+		return nil, err
+	}
+
+	jobScheduler = scheduler.New(database.GetGormDB(), redisClient,
+		scheduler.Job{Name: JobExtendSchedule, Schedule: extendCron, Timeout: jobTimeout, Run: extendScheduleJob(weeks)},
+		scheduler.Job{Name: JobAutoEnroll, Schedule: enrollCron, Timeout: jobTimeout, Run: autoEnrollJob},
+	)
+	return jobScheduler, nil
+}
+
+// extendScheduleJob держит расписание сгенерированным на weeks недель вперёд.
+// Уже созданные слоты GenerateRegularSlots пропускает, поэтому ежедневный запуск добавляет только новый день
+func extendScheduleJob(weeks int) func(ctx context.Context) (scheduler.Result, error) {
+	return func(ctx context.Context) (scheduler.Result, error) {
+		errSubs, stats, err := GenerateRegularSlots(weeks)
+		raised := stats.AgeErrors
+		if len(errSubs) > 0 {
+			raised += saveSubErrors(errSubs)
+		}
+		if stats.SlotsCreated > 0 || raised > 0 {
+			utils.InvalidateCacheCtx(context.WithoutCancel(ctx), scheduleCachePatterns...)
+		}
+		return scheduler.Result{SlotsCreated: stats.SlotsCreated, ErrorsRaised: raised}, err
+	}
+}
+
+// autoEnrollJob записывает абонементы на будущие слоты, например после того, как владелец добавил новые абонементы
+func autoEnrollJob(ctx context.Context) (scheduler.Result, error) {
+	db := database.GetGormDB()
+
+	var slots []models.ActivitySlot
+	if err := db.Where("start_time > ?", time.Now().UTC()).Order("start_time ASC").Find(&slots).Error; err != nil {
+		return scheduler.Result{}, fmt.Errorf("failed to find future slots: %w", err)
+	}
+
+	var allErrSubs []models.Subscription
+	var raised int
+	var runErr error
+	for _, slot := range slots {
+		if err := ctx.Err(); err != nil {
+			runErr = err
+			break
+		}
+		errSubs, ageErrors, err := autoEnrollSubscriptions(db, &slot)
+		if err != nil {
+			log.Error().Err(err).Uint("slot_id", slot.ID).Msg("Failed to auto-enroll subscriptions")
+			runErr = err
+		}
+		allErrSubs = append(allErrSubs, errSubs...)
+		raised += ageErrors
+	}
+
+	if len(allErrSubs) > 0 {
+		raised += saveSubErrors(allErrSubs)
+	}
+	utils.InvalidateCacheCtx(context.WithoutCancel(ctx), scheduleCachePatterns...)
+	return scheduler.Result{ErrorsRaised: raised}, runErr
+}
+
+// История запусков фоновых задач и время следующего запуска каждой
+func GetJobRuns() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		db := database.GetGormDB()
+
+		page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid page"})
+			return
+		}
+		size, err := strconv.Atoi(c.DefaultQuery("size", "50"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid size"})
+			return
+		}
+		if page < 1 {
+			page = 1
+		}
+		if size < 1 || size > 100 {
+			size = 50
+		}
+
+		query := db.Model(&models.JobRun{})
+		if job := c.Query("job"); job != "" {
+			query = query.Where("job_name = ?", job)
+		}
+
+		var total int64
+		if err := query.Count(&total).Error; err != nil {
+			log.Error().Err(err).Msg("Failed to count job runs")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get job runs"})
+			return
+		}
+
+		var runs []models.JobRun
+		if err := query.Order("started_at DESC, id DESC").Offset((page - 1) * size).Limit(size).Find(&runs).Error; err != nil {
+			log.Error().Err(err).Msg("Failed to get job runs")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get job runs"})
+			return
+		}
+
+		jobs := gin.H{}
+		if jobScheduler != nil {
+			for name, next := range jobScheduler.Jobs() {
+				if next.IsZero() {
+					jobs[name] = gin.H{"next_run": nil}
+				} else {
+					jobs[name] = gin.H{"next_run": next}
+				}
+			}
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"jobs":  jobs,
+			"runs":  runs,
+			"total": total,
+			"page":  page,
+			"size":  size,
+		})
+	}
+}
+
+// Ручной запуск задачи. Выполняется в фоне, результат появится в истории запусков
+func RunJob() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if jobScheduler == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Scheduler is not running"})
+			return
+		}
+
+		name := c.Param("name")
+		if err := jobScheduler.RunNow(name); err != nil {
+			switch {
+			case errors.Is(err, scheduler.ErrUnknownJob):
+				c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+			case errors.Is(err, scheduler.ErrJobBusy):
+				c.JSON(http.StatusConflict, gin.H{"error": "Job is already running"})
+			default:
+				log.Error().Err(err).Str("job", name).Msg("Failed to start job")
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start job"})
+			}
+			return
+		}
+
+		log.Info().Str("job", name).Msg("Job started manually")
+		c.JSON(http.StatusAccepted, gin.H{"message": "Job started"})
+	}
+}
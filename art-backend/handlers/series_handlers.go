@@ -0,0 +1,397 @@
+package handlers
+
+import (
+	"art/database"
+	"art/models"
+	"art/utils"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Серия разовых записей: одно занятие по шаблону на каждую из N недель
+func CreateBookingSeries() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req models.BookingSeriesRequest
+		var user models.User
+		db := database.GetGormDB()
+		redisClient, err := database.GetRedis()
+		if err != nil {
+			log.Error().Err(err).Msg("Error getting redis")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get redis"})
+			return
+		}
+
+		if err := c.ShouldBindJSON(&req); err != nil {
+			log.Error().Err(err).Msg("Error to bind json")
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data: " + err.Error()})
+			return
+		}
+
+		phoneNumber, _ := c.Get("phone_number")
+		if err := db.Where("phone_number = ?", phoneNumber).First(&user).Error; err != nil {
+			log.Error().Err(err).Msgf("Error finding user with phone number: %v", phoneNumber)
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+
+		if blocked := checkBookingBlock(c, db, user.ID); blocked {
+			return
+		}
+
+		kids, err := resolveBookingKids(db, user.ID, req.Kids, req.UserKidIDs, req.NumberOfKids)
+		if err != nil {
+			respondKidsError(c, err)
+			return
+		}
+
+		var activity models.Activity
+		if err := db.First(&activity, req.ActivityID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("Activity with ID %d not found", req.ActivityID)})
+			return
+		}
+		if !activity.IsRegular {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Серію можна оформити лише на регулярне заняття"})
+			return
+		}
+
+		var tmpl models.ScheduleTemplate
+		if err := db.Where("id = ? AND activity_id = ?", req.TemplateID, activity.ID).First(&tmpl).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Template not found for this activity"})
+			return
+		}
+
+		ages := activity.AgeRange
+		if tmpl.AgeRange.IsSet() {
+			ages = tmpl.AgeRange
+		}
+		if kid := kidOutsideAgeRange(kids, ages); kid != nil {
+			respondAgeError(c, ages, kid)
+			return
+		}
+
+		startDate, err := seriesStartDate(tmpl, time.Now().UTC())
+		if err != nil {
+			log.Error().Err(err).Uint("template_id", tmpl.ID).Msg("Invalid template start time")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid template time"})
+			return
+		}
+		// Недели после конца периода шаблона никогда не получат слотов
+		if tmpl.ValidTo != nil && startDate.AddDate(0, 0, 7*(req.Weeks-1)).After(*tmpl.ValidTo) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":    "Серія виходить за межі періоду дії розкладу",
+				"reason":   "template_period_ended",
+				"valid_to": tmpl.ValidTo,
+			})
+			return
+		}
+
+		series := models.BookingSeries{
+			UserID:       user.ID,
+			ActivityID:   activity.ID,
+			TemplateID:   tmpl.ID,
+			PhoneNumber:  user.PhoneNumber,
+			ParentName:   user.Name + " " + user.Surname,
+			NumberOfKids: uint(len(kids)),
+			Kids:         kids,
+			StartDate:    startDate,
+			Weeks:        req.Weeks,
+			Status:       models.SeriesStatusActive,
+		}
+
+		if err := db.Create(&series).Error; err != nil {
+			log.Error().Err(err).Msg("Failed to create booking series")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create series"})
+			return
+		}
+
+		// Слоты, которые уже сгенерированы, бронируются сразу, остальные — при продлении расписания
+		var slots []models.ActivitySlot
+		if err := db.Where("template_id = ? AND start_time >= ? AND start_time < ?",
+			tmpl.ID, startDate, startDate.AddDate(0, 0, 7*req.Weeks)).
+			Order("start_time ASC").
+			Find(&slots).Error; err != nil {
+			log.Error().Err(err).Uint("series_id", series.ID).Msg("Failed to find slots for series")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to find slots"})
+			return
+		}
+
+		for _, slot := range slots {
+			if _, err := bookSeriesWeek(db, &series, activity, slot); err != nil {
+				log.Error().Err(err).Uint("series_id", series.ID).Uint("slot_id", slot.ID).Msg("Failed to book series week")
+			}
+		}
+
+		if err := db.Preload("WeekResults", func(db *gorm.DB) *gorm.DB {
+			return db.Order("slot_date ASC")
+		}).First(&series, series.ID).Error; err != nil {
+			log.Error().Err(err).Uint("series_id", series.ID).Msg("Failed to reload series")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load series"})
+			return
+		}
+
+		log.Info().Uint("series_id", series.ID).Uint("user_id", user.ID).Int("weeks", series.Weeks).Msg("Booking series created")
+
+		if redisClient != nil {
+			utils.InvalidateCache(c,
+				"/records",
+				"records:all:*",
+				fmt.Sprintf("client:records:%s:*", user.PhoneNumber),
+				fmt.Sprintf("/activity/%d/slots*", activity.ID),
+			)
+		}
+
+		c.JSON(http.StatusCreated, models.ToBookingSeriesResponse(series))
+	}
+}
+
+func GetMySeries() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var user models.User
+		db := database.GetGormDB()
+
+		phoneNumber, _ := c.Get("phone_number")
+		if err := db.Where("phone_number = ?", phoneNumber).First(&user).Error; err != nil {
+			log.Error().Err(err).Msgf("Error finding user with phone number: %v", phoneNumber)
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+
+		var series []models.BookingSeries
+		if err := db.Where("user_id = ?", user.ID).
+			Preload("WeekResults", func(db *gorm.DB) *gorm.DB {
+				return db.Order("slot_date ASC")
+			}).
+			Order("created_at DESC").
+			Find(&series).Error; err != nil {
+			log.Error().Err(err).Uint("user_id", user.ID).Msg("Failed to get booking series")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch series"})
+			return
+		}
+
+		response := make([]models.BookingSeriesResponse, len(series))
+		for i, s := range series {
+			response[i] = models.ToBookingSeriesResponse(s)
+		}
+
+		c.JSON(http.StatusOK, gin.H{"series": response})
+	}
+}
+
+// Остановка серии: новые недели больше не бронируются, уже созданные записи остаются и отменяются отдельно
+func CancelSeries() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var user models.User
+		var series models.BookingSeries
+		db := database.GetGormDB()
+
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+			return
+		}
+
+		phoneNumber, _ := c.Get("phone_number")
+		if err := db.Where("phone_number = ?", phoneNumber).First(&user).Error; err != nil {
+			log.Error().Err(err).Msgf("Error finding user with phone number: %v", phoneNumber)
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+
+		if err := db.Where("id = ? AND user_id = ?", id, user.ID).First(&series).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Series not found"})
+				return
+			}
+			log.Error().Err(err).Int("id", id).Msg("Failed to get series")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch series"})
+			return
+		}
+
+		if err := db.Model(&series).Update("status", models.SeriesStatusCancelled).Error; err != nil {
+			log.Error().Err(err).Int("id", id).Msg("Failed to cancel series")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel series"})
+			return
+		}
+
+		log.Info().Int("id", id).Uint("user_id", user.ID).Msg("Booking series cancelled")
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// bookSeriesForSlot бронирует новый слот для всех активных серий его шаблона, в которые попадает дата слота
+func bookSeriesForSlot(db *gorm.DB, slot *models.ActivitySlot) error {
+	if slot.TemplateID == nil {
+		return nil
+	}
+
+	slotDate := dateOnly(slot.StartTime)
+
+	var seriesList []models.BookingSeries
+	if err := db.Where("template_id = ? AND status = ? AND start_date <= ? AND start_date + weeks * 7 > ?",
+		*slot.TemplateID, models.SeriesStatusActive, slotDate, slotDate).
+		Order("created_at ASC, id ASC").
+		Find(&seriesList).Error; err != nil {
+		return fmt.Errorf("failed to find booking series: %w", err)
+	}
+
+	if len(seriesList) == 0 {
+		return nil
+	}
+
+	var activity models.Activity
+	if err := db.First(&activity, slot.ActivityID).Error; err != nil {
+		return fmt.Errorf("failed to find activity: %w", err)
+	}
+
+	for i := range seriesList {
+		week, err := bookSeriesWeek(db, &seriesList[i], activity, *slot)
+		if err != nil {
+			log.Error().Err(err).Uint("series_id", seriesList[i].ID).Uint("slot_id", slot.ID).Msg("Failed to book series week")
+			continue
+		}
+		if week.Status == models.SeriesWeekSkipped {
+			log.Info().Uint("series_id", seriesList[i].ID).Str("reason", week.Reason).Msgf("Series week skipped for slot %d", slot.ID)
+		}
+	}
+	return nil
+}
+
+// bookSeriesWeek пытается записать серию на слот и сохраняет результат недели.
+// Неделя, которая уже обработана, повторно не бронируется
+func bookSeriesWeek(db *gorm.DB, series *models.BookingSeries, activity models.Activity, slot models.ActivitySlot) (models.BookingSeriesWeek, error) {
+	week := models.BookingSeriesWeek{
+		SeriesID: series.ID,
+		SlotDate: dateOnly(slot.StartTime),
+		SlotID:   &slot.ID,
+		Status:   models.SeriesWeekSkipped,
+	}
+
+	var processed int64
+	if err := db.Model(&models.BookingSeriesWeek{}).
+		Where("series_id = ? AND slot_date = ?", series.ID, week.SlotDate).
+		Count(&processed).Error; err != nil {
+		return week, err
+	}
+	if processed > 0 {
+		return week, nil
+	}
+
+	tx := db.Begin()
+
+	if _, err := reserveSeats(tx, slot.ID, int(series.NumberOfKids)); err != nil {
+		tx.Rollback()
+		switch {
+		case errors.Is(err, errSlotFull):
+			week.Reason = "full"
+		case errors.Is(err, errSlotInPast):
+			week.Reason = "in_past"
+		case errors.Is(err, errSlotNotFound):
+			week.Reason = "no_slot"
+		default:
+			return week, err
+		}
+		return week, saveSeriesWeek(db, &week)
+	}
+
+	duplicate, err := findDuplicateKid(tx, slot.ID, series.Kids)
+	if err != nil {
+		tx.Rollback()
+		return week, err
+	}
+	if duplicate != nil {
+		tx.Rollback()
+		week.Reason = "duplicate"
+		return week, saveSeriesWeek(db, &week)
+	}
+
+	price, err := quoteRecord(tx, series.UserID, activity, slot, series.NumberOfKids, series.CreatedAt)
+	if err != nil {
+		tx.Rollback()
+		return week, err
+	}
+
+	record := models.Record{
+		UserID:         &series.UserID,
+		SeriesID:       &series.ID,
+		PhoneNumber:    series.PhoneNumber,
+		ParentName:     series.ParentName,
+		TotalPrice:     price.Total,
+		PriceBreakdown: &price,
+		SlotID:         slot.ID,
+		Details: models.RecordDetail{
+			ActivityID:   activity.ID,
+			ActivityName: activity.Name,
+			NumberOfKids: series.NumberOfKids,
+			Date:         slot.StartTime.UTC(),
+		},
+		Kids: models.NewRecordKids(slot.ID, series.Kids),
+	}
+
+	if err := tx.Create(&record).Error; err != nil {
+		tx.Rollback()
+		return week, fmt.Errorf("failed to create series record: %w", err)
+	}
+
+	week.Status = models.SeriesWeekBooked
+	week.RecordID = &record.ID
+	if err := saveSeriesWeek(tx, &week); err != nil {
+		tx.Rollback()
+		return week, err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return week, fmt.Errorf("commit failed for series week: %w", err)
+	}
+
+	log.Info().Uint("series_id", series.ID).Uint("record_id", record.ID).Msgf("Series week booked on slot %d", slot.ID)
+	return week, nil
+}
+
+func saveSeriesWeek(tx *gorm.DB, week *models.BookingSeriesWeek) error {
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(week).Error; err != nil {
+		return fmt.Errorf("failed to save series week: %w", err)
+	}
+	return nil
+}
+
+// seriesStartDate возвращает дату ближайшего ещё не начавшегося занятия по шаблону с учётом начала его периода
+func seriesStartDate(tmpl models.ScheduleTemplate, now time.Time) (time.Time, error) {
+	tmplTime, err := utils.ParseTemplateTime(tmpl.StartTime)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	date := dateOnly(now)
+	if tmpl.ValidFrom != nil && tmpl.ValidFrom.After(date) {
+		date = *tmpl.ValidFrom // Шаблон следующего сезона — серия начинается с его первого дня
+	}
+	for isoWeekday(date) != tmpl.DayOfWeek {
+		date = date.AddDate(0, 0, 1)
+	}
+	if !utils.CombineDateAndTemplateTime(date, tmplTime).After(now) {
+		date = date.AddDate(0, 0, 7)
+	}
+	return date, nil
+}
+
+func dateOnly(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// Номер дня недели как в ScheduleTemplate.DayOfWeek: 1 — понедельник, 7 — воскресенье
+func isoWeekday(t time.Time) int {
+	day := int(t.Weekday())
+	if day == 0 {
+		return 7
+	}
+	return day
+}
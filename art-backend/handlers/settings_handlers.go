@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"art/database"
+	"art/models"
+	"art/utils"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+const studioSettingsCacheKey = "studio:settings"
+
+// loadStudioSettings достаёт единственную строку настроек студии, при её отсутствии отдаёт значения по умолчанию
+func loadStudioSettings(db *gorm.DB) (models.StudioSettings, error) {
+	var settings models.StudioSettings
+	if err := db.First(&settings, 1).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Warn().Msg("Studio settings row missing, using defaults")
+			return models.DefaultStudioSettings(), nil
+		}
+		return settings, err
+	}
+	return settings, nil
+}
+
+func GetStudioSettings() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		db := database.GetGormDB()
+		redisClient, err := database.GetRedis()
+		if err != nil {
+			log.Error().Err(err).Msg("Error getting redis")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get redis"})
+			return
+		}
+
+		var settings models.StudioSettings
+
+		if redisClient != nil {
+			if cached, err := redisClient.Get(c, studioSettingsCacheKey).Result(); err == nil {
+				if json.Unmarshal([]byte(cached), &settings) == nil {
+					c.JSON(http.StatusOK, settings)
+					return
+				}
+			}
+		}
+
+		settings, err = loadStudioSettings(db)
+		if err != nil {
+			log.Error().Err(err).Msg("Error finding studio settings")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load studio settings"})
+			return
+		}
+
+		if redisClient != nil {
+			if respBytes, err := json.Marshal(settings); err == nil {
+				redisClient.Set(c, studioSettingsCacheKey, respBytes, 30*time.Minute)
+			}
+		}
+
+		c.JSON(http.StatusOK, settings)
+	}
+}
+
+func UpdateStudioSettings() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input models.StudioSettingsInput
+		db := database.GetGormDB()
+		redisClient, err := database.GetRedis()
+		if err != nil {
+			log.Error().Err(err).Msg("Error getting redis")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get redis"})
+			return
+		}
+
+		if err := c.ShouldBindJSON(&input); err != nil {
+			log.Error().Err(err).Msg("Error binding json")
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+			return
+		}
+
+		if input.WorkingHours != nil {
+			if err := validateWorkingHours(*input.WorkingHours); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+
+		settings, err := loadStudioSettings(db)
+		if err != nil {
+			log.Error().Err(err).Msg("Error finding studio settings")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load studio settings"})
+			return
+		}
+
+		if input.CancelWindowHours != nil {
+			settings.CancelWindowHours = *input.CancelWindowHours
+		}
+		if input.VisitsByAttendance != nil {
+			settings.VisitsByAttendance = *input.VisitsByAttendance
+		}
+		if input.NoShowBurnsVisit != nil {
+			settings.NoShowBurnsVisit = *input.NoShowBurnsVisit
+		}
+		if input.NoShowLimit != nil {
+			settings.NoShowLimit = *input.NoShowLimit
+		}
+		if input.NoShowPeriodDays != nil {
+			settings.NoShowPeriodDays = *input.NoShowPeriodDays
+		}
+		if input.NoShowBlockDays != nil {
+			settings.NoShowBlockDays = *input.NoShowBlockDays
+		}
+		if input.LateCancelAsNoShow != nil {
+			settings.LateCancelAsNoShow = *input.LateCancelAsNoShow
+		}
+		if input.HoldTTLMinutes != nil {
+			settings.HoldTTLMinutes = *input.HoldTTLMinutes
+		}
+		if input.WorkingHours != nil {
+			settings.WorkingHours = *input.WorkingHours
+		}
+		if input.SiblingDiscountPercent != nil {
+			settings.SiblingDiscountPercent = *input.SiblingDiscountPercent
+		}
+		if input.EarlyBirdDays != nil {
+			settings.EarlyBirdDays = *input.EarlyBirdDays
+		}
+		if input.EarlyBirdDiscountPercent != nil {
+			settings.EarlyBirdDiscountPercent = *input.EarlyBirdDiscountPercent
+		}
+		if input.MemberDiscountPercent != nil {
+			settings.MemberDiscountPercent = *input.MemberDiscountPercent
+		}
+		if input.StudioName != nil {
+			settings.StudioName = *input.StudioName
+		}
+		if input.StudioAddress != nil {
+			settings.StudioAddress = *input.StudioAddress
+		}
+		if input.StudioPhone != nil {
+			settings.StudioPhone = *input.StudioPhone
+		}
+		if input.StudioTaxID != nil {
+			settings.StudioTaxID = *input.StudioTaxID
+		}
+
+		tx := db.Begin()
+		if err := tx.Save(&settings).Error; err != nil {
+			tx.Rollback()
+			log.Error().Err(err).Msg("Failed to save studio settings")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save studio settings"})
+			return
+		}
+
+		if err := tx.Commit().Error; err != nil {
+			log.Error().Err(err).Msg("Commit failed for update studio settings")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Transaction failed"})
+			return
+		}
+
+		if redisClient != nil {
+			utils.InvalidateCache(c, studioSettingsCacheKey)
+		}
+
+		c.JSON(http.StatusOK, settings)
+	}
+}
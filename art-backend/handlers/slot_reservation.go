@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"art/models"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	errSlotNotFound = errors.New("slot not found")
+	errSlotFull     = errors.New("not enough free places in slot")
+	errSlotInPast   = errors.New("slot already started")
+)
+
+// reserveSeats блокирует строку слота до конца транзакции и занимает в нём seats мест.
+// Параллельные бронирования того же слота ждут освобождения блокировки и видят уже обновлённый booked
+func reserveSeats(tx *gorm.DB, slotID uint, seats int) (models.ActivitySlot, error) {
+	return reserveHeldSeats(tx, slotID, seats, 0)
+}
+
+// reserveHeldSeats занимает места так же, как reserveSeats, но не считает занятыми места холда holdID,
+// который как раз превращается в запись
+func reserveHeldSeats(tx *gorm.DB, slotID uint, seats int, holdID uint) (models.ActivitySlot, error) {
+	slot, err := lockFreeSeats(tx, slotID, seats, holdID)
+	if err != nil {
+		return slot, err
+	}
+
+	if err := tx.Model(&slot).UpdateColumn("booked", gorm.Expr("booked + ?", seats)).Error; err != nil {
+		return slot, fmt.Errorf("failed to update slot booked: %w", err)
+	}
+	slot.Booked += seats
+
+	return slot, nil
+}
+
+// lockFreeSeats блокирует слот и проверяет, что в нём есть seats свободных мест с учётом активных холдов
+func lockFreeSeats(tx *gorm.DB, slotID uint, seats int, excludeHoldID uint) (models.ActivitySlot, error) {
+	var slot models.ActivitySlot
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&slot, slotID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return slot, errSlotNotFound
+		}
+		return slot, fmt.Errorf("failed to lock slot: %w", err)
+	}
+
+	if slot.StartTime.IsZero() || slot.StartTime.Before(time.Now()) {
+		return slot, errSlotInPast
+	}
+
+	held, err := heldSeats(tx, slot.ID, excludeHoldID)
+	if err != nil {
+		return slot, err
+	}
+	slot.Held = held
+
+	if !hasFreeSeats(slot, seats) {
+		return slot, errSlotFull
+	}
+
+	return slot, nil
+}
+
+// hasFreeSeats проверяет, поместятся ли в слот ещё seats детей. Места под активными холдами заняты
+func hasFreeSeats(slot models.ActivitySlot, seats int) bool {
+	return slot.Booked+slot.Held+seats <= slot.Capacity
+}
+
+// heldSeats считает места под активными холдами слота, кроме excludeHoldID
+func heldSeats(tx *gorm.DB, slotID uint, excludeHoldID uint) (int, error) {
+	var held int
+	if err := tx.Model(&models.SlotHold{}).
+		Select("COALESCE(SUM(number_of_kids), 0)").
+		Where("slot_id = ? AND status = ? AND expires_at > ? AND id <> ?", slotID, models.HoldStatusActive, time.Now().UTC(), excludeHoldID).
+		Scan(&held).Error; err != nil {
+		return 0, fmt.Errorf("failed to count held seats: %w", err)
+	}
+	return held, nil
+}
+
+// fillHeldSeats проставляет слотам количество мест под активными холдами
+func fillHeldSeats(db *gorm.DB, slots []models.ActivitySlot) error {
+	if len(slots) == 0 {
+		return nil
+	}
+
+	ids := make([]uint, len(slots))
+	for i, slot := range slots {
+		ids[i] = slot.ID
+	}
+
+	var rows []struct {
+		SlotID uint
+		Held   int
+	}
+	if err := db.Model(&models.SlotHold{}).
+		Select("slot_id, SUM(number_of_kids) AS held").
+		Where("slot_id IN ? AND status = ? AND expires_at > ?", ids, models.HoldStatusActive, time.Now().UTC()).
+		Group("slot_id").
+		Scan(&rows).Error; err != nil {
+		return fmt.Errorf("failed to count held seats: %w", err)
+	}
+
+	held := make(map[uint]int, len(rows))
+	for _, row := range rows {
+		held[row.SlotID] = row.Held
+	}
+	for i := range slots {
+		slots[i].Held = held[slots[i].ID]
+	}
+	return nil
+}
+
+// lockSlots блокирует слоты строго по возрастанию id, чтобы встречные переносы не ловили дедлок
+func lockSlots(tx *gorm.DB, slotIDs ...uint) error {
+	ids := slices.Clone(slotIDs)
+	slices.Sort(ids)
+	for _, id := range slices.Compact(ids) {
+		var slot models.ActivitySlot
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&slot, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				continue
+			}
+			return fmt.Errorf("failed to lock slot %d: %w", id, err)
+		}
+	}
+	return nil
+}
+
+// releaseSeats возвращает seats мест в слот, не опуская booked ниже нуля. Слот должен быть уже заблокирован
+func releaseSeats(tx *gorm.DB, slotID uint, seats int) error {
+	if err := tx.Model(&models.ActivitySlot{}).
+		Where("id = ?", slotID).
+		UpdateColumn("booked", gorm.Expr("GREATEST(booked - ?, 0)", seats)).Error; err != nil {
+		return fmt.Errorf("failed to release slot places: %w", err)
+	}
+	return nil
+}
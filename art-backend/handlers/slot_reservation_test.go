@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"art/models"
+	"testing"
+)
+
+func TestHasFreeSeats(t *testing.T) {
+	tests := []struct {
+		name     string
+		capacity int
+		booked   int
+		held     int
+		seats    int
+		want     bool
+	}{
+		{name: "empty slot", capacity: 5, seats: 1, want: true},
+		{name: "fills the last seat", capacity: 5, booked: 4, seats: 1, want: true},
+		{name: "fills the slot at once", capacity: 5, seats: 5, want: true},
+		{name: "one seat too many", capacity: 5, booked: 4, seats: 2},
+		{name: "full slot", capacity: 5, booked: 5, seats: 1},
+		{name: "legacy overbooked slot", capacity: 5, booked: 6, seats: 1},
+		{name: "zero capacity", capacity: 0, seats: 1},
+		{name: "holds take seats", capacity: 5, booked: 2, held: 3, seats: 1},
+		{name: "holds leave the last seat", capacity: 5, booked: 2, held: 2, seats: 1, want: true},
+		{name: "only holds", capacity: 5, held: 5, seats: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			slot := models.ActivitySlot{Capacity: tt.capacity, Booked: tt.booked, Held: tt.held}
+			if got := hasFreeSeats(slot, tt.seats); got != tt.want {
+				t.Errorf("hasFreeSeats(capacity %d, booked %d, held %d, seats %d) = %t, want %t",
+					tt.capacity, tt.booked, tt.held, tt.seats, got, tt.want)
+			}
+		})
+	}
+}
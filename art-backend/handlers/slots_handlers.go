@@ -60,7 +60,21 @@ func GetActivitySlots() gin.HandlerFunc {
 			return
 		}
 
-		c.JSON(http.StatusOK, slots)
+		// Места под холдами заняты, пока клиент оформляет запись
+		if err := fillHeldSeats(db, slots); err != nil {
+			log.Error().Err(err).Msg("Error counting held seats")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch slots"})
+			return
+		}
+
+		available := make([]models.ActivitySlot, 0, len(slots))
+		for _, slot := range slots {
+			if slot.FreePlaces() > 0 {
+				available = append(available, slot)
+			}
+		}
+
+		c.JSON(http.StatusOK, available)
 	}
 }
 
@@ -108,6 +122,12 @@ func GetSlotByID() gin.HandlerFunc {
 			return
 		}
 
+		held, err := heldSeats(db, slot.ID, 0)
+		if err != nil {
+			log.Error().Err(err).Msgf("Error counting held seats for slot: %d", id)
+		}
+		slot.Held = held
+
 		c.JSON(http.StatusOK, &slot)
 	}
 }
@@ -153,6 +173,7 @@ func AddSlot() gin.HandlerFunc {
 
 		slot.ActivityID = uint(activityID)
 		slot.Capacity = input.Capacity
+		slot.PriceOverride = input.PriceOverride
 		slot.Booked = 0
 		slot.Source = "manual"
 
@@ -202,7 +223,8 @@ func UpdateSlot() gin.HandlerFunc {
 			return
 		}
 
-		if err := c.ShouldBindJSON(&input_slot); err != nil {
+		fields, err := bindJSONFields(c, &input_slot)
+		if err != nil {
 			log.Error().Err(err).Msg("Error binding json")
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to bind json"})
 			return
@@ -227,15 +249,67 @@ func UpdateSlot() gin.HandlerFunc {
 				tx.Rollback()
 			}
 		}()
-		if res := tx.Model(&slot).Clauses(clause.Returning{}).Updates(map[string]interface{}{
+
+		// Блокируем слот, чтобы booked не изменился между проверкой и обновлением вместимости
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&slot, id).Error; err != nil {
+			tx.Rollback()
+			log.Error().Err(err).Msgf("Error locking slot by id: %d", id)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to lock slot"})
+			return
+		}
+
+		slot.Held, err = heldSeats(tx, slot.ID, 0)
+		if err != nil {
+			tx.Rollback()
+			log.Error().Err(err).Msgf("Error counting held seats for slot: %d", id)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch slot"})
+			return
+		}
+
+		// Места под активными холдами уже обещаны клиентам в корзине
+		if input_slot.Capacity < slot.Booked+slot.Held {
+			tx.Rollback()
+			c.JSON(http.StatusConflict, gin.H{
+				"error":  "Вмістимість не може бути меншою за кількість уже записаних і заброньованих дітей",
+				"booked": slot.Booked,
+				"held":   slot.Held,
+			})
+			return
+		}
+
+		previousCapacity := slot.Capacity
+		updates := map[string]interface{}{
 			"start_time": input_slot.StartTime,
 			"capacity":   input_slot.Capacity, // Разрешается перезаписывать только некоторые блоки
-		}); res.Error != nil {
+		}
+		if hasJSONField(fields, "price_override") { // Явный null возвращает слоту цену занятия
+			updates["price_override"] = input_slot.PriceOverride
+		}
+		if res := tx.Model(&slot).Clauses(clause.Returning{}).Updates(updates); res.Error != nil {
 			tx.Rollback()
 			log.Error().Err(res.Error).Msg("Error updating slot")
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to update slot"})
 			return
 		}
+
+		var promoted []models.Record
+		if slot.Capacity > previousCapacity { // Новые места достаются листу ожидания
+			promoted, err = promoteWaitlist(tx, slot.ID)
+			if err != nil {
+				tx.Rollback()
+				log.Error().Err(err).Uint("slot_id", slot.ID).Msg("Failed to promote waitlist")
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to promote waitlist"})
+				return
+			}
+			if len(promoted) > 0 {
+				if err := tx.First(&slot, slot.ID).Error; err != nil {
+					tx.Rollback()
+					log.Error().Err(err).Msgf("Error reloading slot by id: %d", id)
+					c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch slot"})
+					return
+				}
+			}
+		}
 		if err := tx.Commit().Error; err != nil {
 			log.Error().Err(err).Msg("Commit failed")
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Transaction failed"})
@@ -251,6 +325,10 @@ func UpdateSlot() gin.HandlerFunc {
 
 		if redisClient != nil {
 			utils.InvalidateCache(c, fmt.Sprintf("/activity/%d/slots*", slot.ActivityID))
+			if len(promoted) > 0 {
+				utils.InvalidateCache(c, "/records*", "records:all:*")
+				invalidatePromotedCache(c, promoted)
+			}
 		}
 
 		c.JSON(http.StatusOK, gin.H{"slot": slot})
@@ -282,9 +360,11 @@ func DeleteSlot() gin.HandlerFunc {
 			return
 		}
 
+		changedBy := currentUserID(c, db)
+
 		tx := db.Begin()
 		var records []models.Record
-		if err := tx.Where("slot_id = ?", slot.ID).Find(&records).Error; err != nil {
+		if err := tx.Where("slot_id = ? AND status IN ?", slot.ID, models.ActiveRecordStatuses).Find(&records).Error; err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
 				log.Warn().
 					Msgf("records missing by slot_id %d, deleting record without restoring sub visits", slot.ID)
@@ -302,7 +382,7 @@ func DeleteSlot() gin.HandlerFunc {
 						log.Warn().
 							Msg("sub_type missing, deleting record without restoring sub visits")
 					} else {
-						log.Error().Err(err).Msgf("Error finding subscription by record user id: %d", record.UserID)
+						log.Error().Err(err).Msgf("Error finding subscription by record id: %d", record.ID)
 						c.JSON(http.StatusNotFound, gin.H{"error": "Failed to find record subscription"})
 						return
 					}
@@ -317,13 +397,13 @@ func DeleteSlot() gin.HandlerFunc {
 						log.Warn().
 							Msg("subscription missing, deleting record without restoring sub visits")
 					} else {
-						log.Error().Err(err).Msgf("Error finding subscription by record user id: %d", record.UserID)
+						log.Error().Err(err).Msgf("Error finding subscription by record id: %d", record.ID)
 						c.JSON(http.StatusNotFound, gin.H{"error": "Failed to find record subscription"})
 						return
 					}
 				} else {
 
-					if subscription.VisitsUsed > 0 {
+					if record.VisitCounted && subscription.VisitsUsed > 0 { // Визит возвращается, только если был списан
 						subscription.VisitsUsed -= 1
 					}
 
@@ -335,15 +415,19 @@ func DeleteSlot() gin.HandlerFunc {
 					}
 				}
 
-				if err := tx.Delete(&record).Error; err != nil {
+				if err := setRecordStatus(tx, &record, models.RecordStatusCancelledByStudio, "Заняття скасовано", changedBy); err != nil {
 					tx.Rollback()
-					log.Error().Err(err).Msgf("Error deleting record id: %d", record.ID)
-					c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete record"})
+					log.Error().Err(err).Msgf("Error cancelling record id: %d", record.ID)
+					c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel record"})
 					return
 				}
 
+				if record.UserID == nil { // Гость без аккаунта, записанный владельцем
+					continue
+				}
+
 				var user models.User
-				if err := db.First(&user, record.UserID).Error; err != nil {
+				if err := db.First(&user, *record.UserID).Error; err != nil {
 					tx.Rollback()
 					log.Error().Err(err).Msgf("Error finding user id: %d", subscription.UserID)
 					c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error while deleting slot"})
@@ -354,6 +438,15 @@ func DeleteSlot() gin.HandlerFunc {
 			}
 		}
 
+		if err := tx.Model(&models.WaitlistEntry{}).
+			Where("slot_id = ? AND status = ?", slot.ID, models.WaitlistStatusWaiting).
+			Update("status", models.WaitlistStatusCancelled).Error; err != nil {
+			tx.Rollback()
+			log.Error().Err(err).Msgf("Error cancelling waitlist by slot id: %d", id)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel slot waitlist"})
+			return
+		}
+
 		if err := tx.Delete(&slot).Error; err != nil {
 			tx.Rollback()
 			log.Error().Err(err).Msgf("Error deleting slot id: %d", id)
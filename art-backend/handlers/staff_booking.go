@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"art/database"
+	"art/models"
+	"art/utils"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+var (
+	errClientNotFound       = errors.New("client not found")
+	errGuestDetailsRequired = errors.New("guest name and phone are required")
+)
+
+// Клиент, за которого владелец оформляет запись. User пустой у гостя без аккаунта
+type staffClient struct {
+	User        *models.User
+	PhoneNumber string
+	ParentName  string
+	Created     bool // Облегчённый аккаунт заведён этим запросом
+}
+
+// resolveStaffClient находит клиента по user_id или телефону. Если по телефону никого нет,
+// записывается гость, при create_account — с облегчённым аккаунтом
+func resolveStaffClient(tx *gorm.DB, req models.StaffRecordRequest) (staffClient, error) {
+	var user models.User
+
+	if req.UserID != nil {
+		if err := tx.First(&user, *req.UserID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return staffClient{}, errClientNotFound
+			}
+			return staffClient{}, fmt.Errorf("failed to find user: %w", err)
+		}
+		return staffClient{User: &user, PhoneNumber: user.PhoneNumber, ParentName: user.Name + " " + user.Surname}, nil
+	}
+
+	if req.PhoneNumber == "" {
+		return staffClient{}, errGuestDetailsRequired
+	}
+
+	err := tx.Where("phone_number = ?", req.PhoneNumber).First(&user).Error
+	if err == nil {
+		return staffClient{User: &user, PhoneNumber: user.PhoneNumber, ParentName: user.Name + " " + user.Surname}, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return staffClient{}, fmt.Errorf("failed to find user by phone: %w", err)
+	}
+
+	if req.GuestName == "" {
+		return staffClient{}, errGuestDetailsRequired
+	}
+
+	client := staffClient{PhoneNumber: req.PhoneNumber, ParentName: req.GuestName}
+	if !req.CreateAccount {
+		return client, nil
+	}
+
+	guest, err := newGuestUser(req.PhoneNumber, req.GuestName)
+	if err != nil {
+		return staffClient{}, err
+	}
+	if err := tx.Create(&guest).Error; err != nil {
+		return staffClient{}, fmt.Errorf("failed to create guest account: %w", err)
+	}
+	client.User = &guest
+	client.Created = true
+	return client, nil
+}
+
+// newGuestUser собирает облегчённый аккаунт: логин по телефону и случайный пароль, который никто не знает.
+// Клиент получает доступ, зарегистрировавшись с тем же телефоном
+func newGuestUser(phone, name string) (models.User, error) {
+	secret := make([]byte, 16)
+	if _, err := rand.Read(secret); err != nil {
+		return models.User{}, fmt.Errorf("failed to generate guest password: %w", err)
+	}
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(hex.EncodeToString(secret)), bcrypt.DefaultCost)
+	if err != nil {
+		return models.User{}, fmt.Errorf("failed to hash guest password: %w", err)
+	}
+
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, phone)
+
+	first, last, _ := strings.Cut(strings.TrimSpace(name), " ")
+	return models.User{
+		Username:    "guest" + digits,
+		Password:    string(hashedPassword),
+		PhoneNumber: phone,
+		Role:        "client",
+		Name:        first,
+		Surname:     strings.TrimSpace(last),
+		IsGuest:     true,
+	}, nil
+}
+
+// Запись, оформленная владельцем за клиента по телефону или на месте.
+// Блокировка самозаписи за неявки здесь не действует: решение принимает владелец
+func StaffMakeRecord() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req models.StaffRecordRequest
+		db := database.GetGormDB()
+
+		if err := c.ShouldBindJSON(&req); err != nil {
+			log.Error().Err(err).Msg("Error to bind json")
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data: " + err.Error()})
+			return
+		}
+		req.PhoneNumber = strings.TrimSpace(req.PhoneNumber)
+		req.GuestName = strings.TrimSpace(req.GuestName)
+
+		staffID := currentUserID(c, db)
+
+		tx := db.Begin()
+		defer func() {
+			if r := recover(); r != nil {
+				tx.Rollback()
+			}
+		}()
+
+		client, err := resolveStaffClient(tx, req)
+		if err != nil {
+			tx.Rollback()
+			switch {
+			case errors.Is(err, errClientNotFound):
+				c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			case errors.Is(err, errGuestDetailsRequired):
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Вкажіть user_id або телефон клієнта, для нового клієнта — ще й guest_name"})
+			case isUniqueViolation(err):
+				c.JSON(http.StatusConflict, gin.H{"error": "Guest account for this phone cannot be created, username is taken"})
+			default:
+				log.Error().Err(err).Msg("Error resolving client for staff booking")
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user"})
+			}
+			return
+		}
+
+		var userID *uint
+		var ownerID uint
+		if client.User != nil {
+			userID = &client.User.ID
+			ownerID = client.User.ID
+		}
+
+		kids, err := resolveBookingKids(tx, ownerID, req.Kids, req.UserKidIDs, req.NumberOfKids)
+		if err != nil {
+			tx.Rollback()
+			respondKidsError(c, err)
+			return
+		}
+
+		record, slot, err := createSlotRecord(tx, slotBooking{
+			UserID:      userID,
+			PhoneNumber: client.PhoneNumber,
+			ParentName:  client.ParentName,
+			ActivityID:  req.ActivityID,
+			SlotID:      req.SlotID,
+			Kids:        kids,
+			PromoCode:   req.PromoCode,
+			ByStaff:     true,
+			CreatedBy:   staffID,
+		})
+		if err != nil {
+			tx.Rollback()
+			respondBookingError(c, slot, err)
+			return
+		}
+
+		if err := tx.Commit().Error; err != nil {
+			log.Error().Err(err).Msg("Commit failed for staff record")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Transaction failed"})
+			return
+		}
+
+		log.Info().Uint("record_id", record.ID).Str("phone", record.PhoneNumber).Bool("guest_account_created", client.Created).
+			Msg("Record created by staff")
+
+		utils.InvalidateCache(c, "/records", "records:all:*", fmt.Sprintf("client:records:%s:*", record.PhoneNumber))
+
+		resp := recordCreatedResponse(record)
+		resp["user_id"] = record.UserID
+		resp["created_by_staff"] = true
+		resp["guest_account_created"] = client.Created
+		c.JSON(http.StatusCreated, resp)
+	}
+}
@@ -3,6 +3,7 @@ package handlers
 import (
 	"art/database"
 	"art/models"
+	"art/pricing"
 	"art/utils"
 	"encoding/json"
 	"errors"
@@ -201,6 +202,24 @@ func AddSubscription() gin.HandlerFunc {
 			}
 		}()
 
+		pricePaid := req.PricePaid
+		var promo models.PromoCode
+		var promoDiscount uint
+		if req.PromoCode != "" {
+			promo, err = lockPromoCode(tx, req.PromoCode, req.UserID, sub_type.ActivityID, &sub_type.ID, time.Now().UTC())
+			if err != nil {
+				tx.Rollback()
+				respondPromoError(c, err)
+				return
+			}
+			if pricePaid == 0 {
+				pricePaid = sub_type.Price
+			}
+			price := pricing.NewEngine(promoRule(promo)).Calculate(pricing.Quote{ActivityPrice: pricePaid, Kids: 1})
+			pricePaid = price.Total
+			promoDiscount = price.Discount(pricing.PromoRuleName)
+		}
+
 		var createdKids []models.SubKid
 		for _, kid := range req.SubKids {
 			if kid.Name == "" {
@@ -227,7 +246,8 @@ func AddSubscription() gin.HandlerFunc {
 			EndDate:            endDate,
 			VisitsTotal:        sub_type.VisitsCount,
 			VisitsUsed:         0,
-			PricePaid:          req.PricePaid,
+			PricePaid:          pricePaid,
+			PromoCode:          req.PromoCode,
 		}
 
 		if res := tx.Create(&sub); res.Error != nil {
@@ -246,6 +266,19 @@ func AddSubscription() gin.HandlerFunc {
 			return
 		}
 
+		if req.PromoCode != "" {
+			if err := redeemPromo(tx, &promo, models.PromoRedemption{
+				UserID:         sub.UserID,
+				SubscriptionID: &sub.ID,
+				Discount:       promoDiscount,
+			}); err != nil {
+				tx.Rollback()
+				log.Error().Err(err).Msg("Error redeeming promo code")
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to apply promo code"})
+				return
+			}
+		}
+
 		tx.Commit()
 
 		db.Preload("SubKids").First(&sub, sub.ID)
@@ -363,7 +396,8 @@ func DeleteSubscription() gin.HandlerFunc {
 		}()
 
 		var records []models.Record
-		if err := tx.Where("subscription_id = ?", sub.ID).Find(&records).Error; err != nil {
+		var releasedSlots []uint
+		if err := tx.Where("subscription_id = ? AND status IN ?", sub.ID, models.ActiveRecordStatuses).Find(&records).Error; err != nil {
 			if !errors.Is(err, gorm.ErrRecordNotFound) {
 				tx.Rollback()
 				log.Error().Err(err).Msgf("Error finding records by subscription id: %d", sub.ID)
@@ -386,10 +420,10 @@ func DeleteSubscription() gin.HandlerFunc {
 						Uint("slot_id", record.SlotID).
 						Msg("slot missing, deleting record without restoring places")
 				} else {
-					if err := tx.Delete(&record).Error; err != nil {
+					if err := setRecordStatus(tx, &record, models.RecordStatusCancelledByStudio, "Абонемент видалено", currentUserID(c, db)); err != nil {
 						tx.Rollback()
-						log.Error().Err(err).Msgf("Error deliting record by id: %d", record.ID)
-						c.JSON(http.StatusInternalServerError, gin.H{"error:": "Failed to delete record"})
+						log.Error().Err(err).Msgf("Error cancelling record by id: %d", record.ID)
+						c.JSON(http.StatusInternalServerError, gin.H{"error:": "Failed to cancel record"})
 						return
 					}
 
@@ -405,10 +439,23 @@ func DeleteSubscription() gin.HandlerFunc {
 						c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to restore places"})
 						return
 					}
+					releasedSlots = append(releasedSlots, slot.ID)
 				}
 			}
 		}
 
+		var promoted []models.Record
+		for _, slotID := range releasedSlots { // Освободившиеся места сразу отдаются листу ожидания
+			slotPromoted, err := promoteWaitlist(tx, slotID)
+			if err != nil {
+				tx.Rollback()
+				log.Error().Err(err).Uint("slot_id", slotID).Msg("Failed to promote waitlist")
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to promote waitlist"})
+				return
+			}
+			promoted = append(promoted, slotPromoted...)
+		}
+
 		if err := tx.Delete(&sub, id).Error; err != nil {
 			tx.Rollback()
 			log.Error().Err(err).Msgf("Error to delete subscription by id: %d", id)
@@ -434,6 +481,7 @@ func DeleteSubscription() gin.HandlerFunc {
 				"client:records:*",
 				"schedule*",
 			)
+			invalidatePromotedCache(c, promoted)
 		}
 
 		c.Status(http.StatusNoContent)
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"art/models"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// applyTemplatePeriod переносит в шаблон период действия и сезон из запроса, на ошибку сам отвечает 400
+func applyTemplatePeriod(c *gin.Context, tmpl *models.ScheduleTemplate, input models.SlotInputGenerate) bool {
+	var from, to *time.Time
+	if input.ValidFrom != "" {
+		date, err := time.Parse("2006-01-02", input.ValidFrom)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "valid_from must be YYYY-MM-DD"})
+			return false
+		}
+		from = &date
+	}
+	if input.ValidTo != "" {
+		date, err := time.Parse("2006-01-02", input.ValidTo)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "valid_to must be YYYY-MM-DD"})
+			return false
+		}
+		to = &date
+	}
+	if from != nil && to != nil && to.Before(*from) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "valid_to must not be before valid_from"})
+		return false
+	}
+
+	tmpl.ValidFrom = from
+	tmpl.ValidTo = to
+	tmpl.Season = strings.TrimSpace(input.Season)
+	return true
+}
+
+// templateOverlaps ищет другой шаблон занятия на тот же день и время, период которого пересекается
+// с периодом tmpl. Пустые границы периода — бесконечность в свою сторону
+func templateOverlaps(db *gorm.DB, tmpl models.ScheduleTemplate) (models.ScheduleTemplate, bool, error) {
+	var other models.ScheduleTemplate
+	query := db.Where("activity_id = ? AND day_of_week = ? AND start_time = ? AND id <> ?",
+		tmpl.ActivityID, tmpl.DayOfWeek, tmpl.StartTime, tmpl.ID)
+	if tmpl.ValidTo != nil {
+		query = query.Where("valid_from IS NULL OR valid_from <= ?", *tmpl.ValidTo)
+	}
+	if tmpl.ValidFrom != nil {
+		query = query.Where("valid_to IS NULL OR valid_to >= ?", *tmpl.ValidFrom)
+	}
+
+	err := query.First(&other).Error
+	if err == nil {
+		return other, true, nil
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return other, false, nil
+	}
+	return other, false, err
+}
+
+func respondTemplateOverlap(c *gin.Context, other models.ScheduleTemplate) {
+	c.JSON(http.StatusConflict, gin.H{
+		"error":       "Шаблон з таким днем та часом вже діє в цей період",
+		"template_id": other.ID,
+		"season":      other.Season,
+		"valid_from":  other.ValidFrom,
+		"valid_to":    other.ValidTo,
+	})
+}
+
+// respondTemplateExclusion отвечает 409, когда пересекающийся шаблон успел сохранить параллельный запрос
+// и сохранение отсекло ограничение excl_schedule_templates_period
+func respondTemplateExclusion(c *gin.Context, db *gorm.DB, tmpl models.ScheduleTemplate) {
+	if other, overlaps, err := templateOverlaps(db, tmpl); err == nil && overlaps {
+		respondTemplateOverlap(c, other)
+		return
+	}
+	c.JSON(http.StatusConflict, gin.H{"error": "Шаблон з таким днем та часом вже діє в цей період"})
+}
+
+// templateFilter добавляет к списку шаблонов фильтры ?season= и ?valid_on=YYYY-MM-DD, на ошибку отвечает 400
+func templateFilter(c *gin.Context, query *gorm.DB) (*gorm.DB, bool) {
+	if season, ok := c.GetQuery("season"); ok {
+		query = query.Where("season = ?", strings.TrimSpace(season))
+	}
+	if value := c.Query("valid_on"); value != "" {
+		day, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "valid_on must be YYYY-MM-DD"})
+			return query, false
+		}
+		query = query.Where("(valid_from IS NULL OR valid_from <= ?) AND (valid_to IS NULL OR valid_to >= ?)", day, day)
+	}
+	return query, true
+}
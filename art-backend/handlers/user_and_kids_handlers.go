@@ -9,6 +9,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"slices"
 	"strconv"
 	"time"
 
@@ -578,3 +579,151 @@ func DeleteKid() gin.HandlerFunc {
 
 	}
 }
+
+// История записей ребёнка из профиля клиента
+func GetKidRecords() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var kid models.UserKid
+		db := database.GetGormDB()
+
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to get id")
+			c.JSON(http.StatusNotFound, gin.H{"error": "Invalid id of kid"})
+			return
+		}
+
+		phoneNumber, ok := c.Get("phone_number")
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		var user models.User
+		if err := db.Where("phone_number = ?", phoneNumber).First(&user).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+
+		query := db.Where("id = ?", id)
+		if user.Role != "owner" {
+			query = query.Where("user_id = ?", user.ID)
+		}
+
+		if err := query.First(&kid).Error; err != nil {
+			log.Error().Err(err).Msgf("Error finding kid by id: %d", id)
+			c.JSON(http.StatusNotFound, gin.H{"error": "Kid not found"})
+			return
+		}
+
+		var records []models.Record
+		if err := db.Joins("JOIN record_kids rk ON rk.record_id = records.id AND rk.deleted_at IS NULL").
+			Where("rk.user_kid_id = ?", kid.ID).
+			Preload("Kids").
+			Order("details->>'date' DESC").
+			Find(&records).Error; err != nil {
+			log.Error().Err(err).Msgf("Error finding records by kid id: %d", kid.ID)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch records"})
+			return
+		}
+
+		response := make([]models.RecordResponse, len(records))
+		for i, rec := range records {
+			response[i] = models.ToRecordResponse(rec)
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"kid":     dto.KidToResponse(kid),
+			"records": response,
+		})
+	}
+}
+
+var (
+	errKidNotOwned       = errors.New("kid does not belong to user")
+	errNoKids            = errors.New("no kids in booking")
+	errKidsCountMismatch = errors.New("number_of_kids does not match kids")
+)
+
+// resolveBookingKids собирает детей брони из анонимного списка и сохранённых профилей клиента.
+// Дети из профиля копируются в том виде, в каком они есть на момент бронирования
+func resolveBookingKids(db *gorm.DB, userID uint, kids []models.Kid, userKidIDs []uint, numberOfKids uint) ([]models.Kid, error) {
+	userKids, err := findOwnKids(db, userID, userKidIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	result := append(anonymousKids(kids), kidsFromUserKids(userKids)...)
+	if len(result) == 0 {
+		return nil, errNoKids
+	}
+	if numberOfKids != 0 && int(numberOfKids) != len(result) {
+		return nil, errKidsCountMismatch
+	}
+	return result, nil
+}
+
+// respondKidsError отвечает клиенту по ошибке resolveBookingKids
+func respondKidsError(c *gin.Context, err error) {
+	c.JSON(kidsErrorBody(err))
+}
+
+func kidsErrorBody(err error) (int, gin.H) {
+	switch {
+	case errors.Is(err, errKidNotOwned):
+		return http.StatusForbidden, gin.H{"error": "Дитина не належить вашому акаунту"}
+	case errors.Is(err, errNoKids):
+		return http.StatusBadRequest, gin.H{"error": "Вкажіть дітей через kids або user_kid_ids"}
+	case errors.Is(err, errKidsCountMismatch):
+		return http.StatusBadRequest, gin.H{"error": "number_of_kids must match the kids list"}
+	default:
+		log.Error().Err(err).Msg("Error finding user kids")
+		return http.StatusInternalServerError, gin.H{"error": "Failed to fetch kids"}
+	}
+}
+
+// findOwnKids загружает детей по id и проверяет, что все они принадлежат пользователю
+func findOwnKids(db *gorm.DB, userID uint, ids []uint) ([]models.UserKid, error) {
+	var kids []models.UserKid
+	if len(ids) == 0 {
+		return kids, nil
+	}
+
+	unique := make(map[uint]struct{}, len(ids))
+	for _, id := range ids {
+		unique[id] = struct{}{}
+	}
+
+	if err := db.Where("id IN ? AND user_id = ?", ids, userID).Find(&kids).Error; err != nil {
+		return nil, err
+	}
+	if len(kids) != len(unique) {
+		return nil, errKidNotOwned
+	}
+
+	return kids, nil
+}
+
+// anonymousKids копирует детей, переданных списком, без user_kid_id: клиент может прислать чужой id,
+// а связь с профилем ставит только kidsFromUserKids после проверки владельца
+func anonymousKids(kids []models.Kid) []models.Kid {
+	result := slices.Clone(kids)
+	for i := range result {
+		result[i].UserKidID = nil
+	}
+	return result
+}
+
+func kidsFromUserKids(userKids []models.UserKid) []models.Kid {
+	kids := make([]models.Kid, len(userKids))
+	for i, k := range userKids {
+		kidID := k.ID
+		kids[i] = models.Kid{
+			Name:      k.Name,
+			Age:       k.Age,
+			Gender:    k.Gender,
+			UserKidID: &kidID,
+		}
+	}
+	return kids
+}
@@ -0,0 +1,404 @@
+package handlers
+
+import (
+	"art/database"
+	"art/models"
+	"art/utils"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Постановка клиента в лист ожидания на заполненный слот
+func JoinWaitlist() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req models.WaitlistRequest
+		var user models.User
+		db := database.GetGormDB()
+
+		slotID, err := strconv.Atoi(c.Param("slot_id"))
+		if err != nil {
+			log.Error().Err(err).Msg("Invalid slot id")
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to fetch slot id"})
+			return
+		}
+
+		if err := c.ShouldBindJSON(&req); err != nil {
+			log.Error().Err(err).Msg("Error to bind json")
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data: " + err.Error()})
+			return
+		}
+
+		req.Kids = anonymousKids(req.Kids) // Связь с профилем ребёнка из запроса не принимается
+
+		if len(req.Kids) != int(req.NumberOfKids) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "number_of_kids must match the kids list"})
+			return
+		}
+
+		phoneNumber, ok := c.Get("phone_number")
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		if err := db.Where("phone_number = ?", phoneNumber).First(&user).Error; err != nil {
+			log.Error().Err(err).Msgf("Error finding user with phone number: %v", phoneNumber)
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+
+		if blocked := checkBookingBlock(c, db, user.ID); blocked {
+			return
+		}
+
+		var slot models.ActivitySlot
+		if err := db.First(&slot, slotID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Слот не найден"})
+				return
+			}
+			log.Error().Err(err).Msgf("Error finding slot by id: %d", slotID)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch slot"})
+			return
+		}
+
+		activityID, _ := strconv.Atoi(c.Param("activity_id"))
+		if slot.ActivityID != uint(activityID) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Slot does not belong to this activity"})
+			return
+		}
+
+		if slot.StartTime.Before(time.Now()) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Дата занятия должна быть в будущем"})
+			return
+		}
+
+		held, err := heldSeats(db, slot.ID, 0)
+		if err != nil {
+			log.Error().Err(err).Msg("Error counting held seats")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check slot places"})
+			return
+		}
+
+		if slot.Booked+held+int(req.NumberOfKids) <= slot.Capacity {
+			c.JSON(http.StatusConflict, gin.H{"error": "На занятті є вільні місця, запишіться напряму"})
+			return
+		}
+
+		if ok := checkKidsAge(c, db, slot, req.Kids); !ok {
+			return
+		}
+
+		var existing int64
+		if err := db.Model(&models.WaitlistEntry{}).
+			Where("slot_id = ? AND user_id = ? AND status = ?", slot.ID, user.ID, models.WaitlistStatusWaiting).
+			Count(&existing).Error; err != nil {
+			log.Error().Err(err).Msg("Error checking waitlist duplicate")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check waitlist"})
+			return
+		}
+		if existing > 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "Ви вже в листі очікування на це заняття"})
+			return
+		}
+
+		entry := models.WaitlistEntry{
+			SlotID:       slot.ID,
+			ActivityID:   slot.ActivityID,
+			UserID:       user.ID,
+			PhoneNumber:  user.PhoneNumber,
+			ParentName:   user.Name + " " + user.Surname,
+			NumberOfKids: req.NumberOfKids,
+			Kids:         req.Kids,
+			Status:       models.WaitlistStatusWaiting,
+		}
+
+		tx := db.Begin()
+		if err := tx.Create(&entry).Error; err != nil {
+			tx.Rollback()
+			log.Error().Err(err).Msg("Failed to create waitlist entry")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to join waitlist"})
+			return
+		}
+
+		if err := tx.Commit().Error; err != nil {
+			log.Error().Err(err).Msg("Commit failed for join waitlist")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Transaction failed"})
+			return
+		}
+
+		position, err := waitlistPosition(db, &entry)
+		if err != nil {
+			log.Error().Err(err).Uint("entry_id", entry.ID).Msg("Failed to count waitlist position")
+		}
+
+		c.JSON(http.StatusCreated, models.ToWaitlistEntryResponse(entry, position))
+	}
+}
+
+func GetMyWaitlist() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var user models.User
+		db := database.GetGormDB()
+
+		phoneNumber, ok := c.Get("phone_number")
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		if err := db.Where("phone_number = ?", phoneNumber).First(&user).Error; err != nil {
+			log.Error().Err(err).Msgf("Error finding user with phone number: %v", phoneNumber)
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+
+		var entries []models.WaitlistEntry
+		var err error
+		if err = db.Where("user_id = ?", user.ID).
+			Order("created_at DESC").
+			Find(&entries).Error; err != nil {
+			log.Error().Err(err).Msgf("Error finding waitlist entries by user id: %d", user.ID)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load waitlist"})
+			return
+		}
+
+		response := make([]models.WaitlistEntryResponse, len(entries))
+		for i, entry := range entries {
+			position := 0
+			if entry.Status == models.WaitlistStatusWaiting {
+				position, err = waitlistPosition(db, &entry)
+				if err != nil {
+					log.Error().Err(err).Uint("entry_id", entry.ID).Msg("Failed to count waitlist position")
+				}
+			}
+			response[i] = models.ToWaitlistEntryResponse(entry, position)
+		}
+
+		c.JSON(http.StatusOK, gin.H{"waitlist": response})
+	}
+}
+
+func LeaveWaitlist() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var user models.User
+		var entry models.WaitlistEntry
+		db := database.GetGormDB()
+
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to get id")
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid id of waitlist entry"})
+			return
+		}
+
+		phoneNumber, ok := c.Get("phone_number")
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		if err := db.Where("phone_number = ?", phoneNumber).First(&user).Error; err != nil {
+			log.Error().Err(err).Msgf("Error finding user with phone number: %v", phoneNumber)
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+
+		if err := db.Where("id = ? AND user_id = ? AND status = ?", id, user.ID, models.WaitlistStatusWaiting).
+			First(&entry).Error; err != nil {
+			log.Error().Err(err).Msgf("Error finding waitlist entry by id: %d", id)
+			c.JSON(http.StatusNotFound, gin.H{"error": "Waitlist entry not found"})
+			return
+		}
+
+		tx := db.Begin()
+		if err := tx.Model(&entry).Update("status", models.WaitlistStatusCancelled).Error; err != nil {
+			tx.Rollback()
+			log.Error().Err(err).Msgf("Error cancelling waitlist entry id: %d", id)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to leave waitlist"})
+			return
+		}
+
+		if err := tx.Commit().Error; err != nil {
+			log.Error().Err(err).Msg("Commit failed for leave waitlist")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Transaction failed"})
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// Очередь ожидания слота для владельца
+func GetSlotWaitlist() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		db := database.GetGormDB()
+
+		slotID, err := strconv.Atoi(c.Param("slot_id"))
+		if err != nil {
+			log.Error().Err(err).Msg("Invalid slot id")
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to fetch slot id"})
+			return
+		}
+
+		var entries []models.WaitlistEntry
+		if err := db.Where("slot_id = ? AND status = ?", slotID, models.WaitlistStatusWaiting).
+			Order("created_at ASC, id ASC").
+			Find(&entries).Error; err != nil {
+			log.Error().Err(err).Msgf("Error finding waitlist by slot id: %d", slotID)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load waitlist"})
+			return
+		}
+
+		response := make([]gin.H, len(entries))
+		for i, entry := range entries {
+			response[i] = gin.H{
+				"entry":        models.ToWaitlistEntryResponse(entry, i+1),
+				"phone_number": entry.PhoneNumber,
+				"parent_name":  entry.ParentName,
+			}
+		}
+
+		c.JSON(http.StatusOK, gin.H{"waitlist": response})
+	}
+}
+
+// Позиция ожидающей заявки в очереди слота, начиная с 1
+func waitlistPosition(db *gorm.DB, entry *models.WaitlistEntry) (int, error) {
+	var ahead int64
+	err := db.Model(&models.WaitlistEntry{}).
+		Where("slot_id = ? AND status = ? AND (created_at < ? OR (created_at = ? AND id < ?))",
+			entry.SlotID, models.WaitlistStatusWaiting, entry.CreatedAt, entry.CreatedAt, entry.ID).
+		Count(&ahead).Error
+	if err != nil {
+		return 0, err
+	}
+	return int(ahead) + 1, nil
+}
+
+// promoteWaitlist переводит в записи первые по очереди заявки, которые помещаются в освободившиеся места.
+// Работает внутри переданной транзакции и блокирует слот
+func promoteWaitlist(tx *gorm.DB, slotID uint) ([]models.Record, error) {
+	var promoted []models.Record
+
+	var slot models.ActivitySlot
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&slot, slotID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return promoted, nil
+		}
+		return promoted, fmt.Errorf("failed to lock slot: %w", err)
+	}
+
+	held, err := heldSeats(tx, slot.ID, 0)
+	if err != nil {
+		return promoted, err
+	}
+	slot.Held = held
+
+	if slot.StartTime.Before(time.Now()) || slot.FreePlaces() == 0 {
+		return promoted, nil
+	}
+
+	var entries []models.WaitlistEntry
+	if err := tx.Where("slot_id = ? AND status = ?", slot.ID, models.WaitlistStatusWaiting).
+		Order("created_at ASC, id ASC").
+		Find(&entries).Error; err != nil {
+		return promoted, fmt.Errorf("failed to load waitlist: %w", err)
+	}
+
+	if len(entries) == 0 {
+		return promoted, nil
+	}
+
+	var activity models.Activity
+	if err := tx.First(&activity, slot.ActivityID).Error; err != nil {
+		return promoted, fmt.Errorf("failed to find activity: %w", err)
+	}
+
+	for _, entry := range entries {
+		if slot.FreePlaces() == 0 {
+			break
+		}
+		if int(entry.NumberOfKids) > slot.FreePlaces() {
+			continue // Большая заявка ждёт, следующие по очереди могут поместиться
+		}
+
+		duplicate, err := findDuplicateKid(tx, slot.ID, entry.Kids)
+		if err != nil {
+			return promoted, err
+		}
+		if duplicate != nil {
+			// Заявка уже не может быть выполнена, иначе она занимала бы очередь и проверялась при каждой отмене
+			log.Warn().Uint("entry_id", entry.ID).Str("kid_name", duplicate.Name).Msg("Kid already booked on slot, cancelling waitlist entry")
+			if err := tx.Model(&entry).Updates(map[string]interface{}{
+				"status":        models.WaitlistStatusCancelled,
+				"status_reason": models.WaitlistReasonKidBooked,
+			}).Error; err != nil {
+				return promoted, fmt.Errorf("failed to cancel waitlist entry: %w", err)
+			}
+			continue
+		}
+
+		price, err := quoteRecord(tx, entry.UserID, activity, slot, entry.NumberOfKids, entry.CreatedAt)
+		if err != nil {
+			return promoted, err
+		}
+
+		record := models.Record{
+			UserID:         &entry.UserID,
+			PhoneNumber:    entry.PhoneNumber,
+			ParentName:     entry.ParentName,
+			TotalPrice:     price.Total,
+			PriceBreakdown: &price,
+			SlotID:         slot.ID,
+			Details: models.RecordDetail{
+				ActivityID:   activity.ID,
+				ActivityName: activity.Name,
+				NumberOfKids: entry.NumberOfKids,
+				Kids:         entry.Kids,
+				Date:         slot.StartTime.UTC(),
+			},
+			Kids: models.NewRecordKids(slot.ID, entry.Kids),
+		}
+
+		if err := tx.Create(&record).Error; err != nil {
+			return promoted, fmt.Errorf("failed to create record from waitlist: %w", err)
+		}
+
+		now := time.Now().UTC()
+		if err := tx.Model(&entry).Updates(map[string]interface{}{
+			"status":      models.WaitlistStatusPromoted,
+			"record_id":   record.ID,
+			"promoted_at": now,
+		}).Error; err != nil {
+			return promoted, fmt.Errorf("failed to update waitlist entry: %w", err)
+		}
+
+		slot.Booked += int(entry.NumberOfKids)
+		promoted = append(promoted, record)
+
+		log.Info().Uint("entry_id", entry.ID).Uint("record_id", record.ID).Msgf("Waitlist entry promoted on slot %d", slot.ID)
+	}
+
+	if len(promoted) > 0 {
+		if err := tx.Model(&slot).UpdateColumn("booked", slot.Booked).Error; err != nil {
+			return promoted, fmt.Errorf("failed to update slot booked: %w", err)
+		}
+	}
+
+	return promoted, nil
+}
+
+func invalidatePromotedCache(c *gin.Context, promoted []models.Record) {
+	for _, record := range promoted {
+		utils.InvalidateCache(c, fmt.Sprintf("client:records:%s:*", record.PhoneNumber))
+	}
+}
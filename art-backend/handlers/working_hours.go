@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"art/models"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// activityWorkingHours — часы занятия, если они заданы, иначе часы студии
+func activityWorkingHours(settings models.StudioSettings, act models.Activity) models.WorkingHours {
+	if act.WorkingHours != nil {
+		return *act.WorkingHours
+	}
+	return settings.WorkingHours
+}
+
+// validateWorkingHours проверяет часы из запроса. Пустое расписание не принимается:
+// выключить занятие целиком можно через is_regular или закрытие
+func validateWorkingHours(hours models.WorkingHours) error {
+	if len(hours) == 0 {
+		return errors.New("working_hours must have at least one working day")
+	}
+	return hours.Validate()
+}
+
+// checkTemplateHours проверяет, что шаблон попадает в рабочие дни и часы занятия, и сам отвечает 400
+func checkTemplateHours(c *gin.Context, hours models.WorkingHours, act models.Activity, dayOfWeek int, startTime string) bool {
+	if dayOfWeek < 1 || dayOfWeek > 7 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "day_of_week must be 1-7 (Mon-Sun)"})
+		return false
+	}
+	if !hours.IsWorkingDay(dayOfWeek) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":         "Студія не працює в цей день тижня",
+			"reason":        "non_working_day",
+			"working_hours": hours,
+		})
+		return false
+	}
+	if !hours.Allows(dayOfWeek, startTime, act.Duration) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":         "Заняття не вкладається в робочі години",
+			"reason":        "outside_working_hours",
+			"working_hours": hours,
+		})
+		return false
+	}
+	return true
+}
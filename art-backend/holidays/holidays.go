@@ -0,0 +1,71 @@
+package holidays
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Список державних свят України по статье 73 КЗпП в редакции 2023 года.
+// Правится вместе с законом, поэтому лежит отдельным файлом, а не в коде
+//
+//go:embed ua.json
+var uaJSON []byte
+
+type Holiday struct {
+	Name  string
+	Date  time.Time // Полночь UTC, как даты занятий
+	Fixed bool      // Каждый год в тот же день; переходящие праздники считаются от Пасхи
+}
+
+type calendar struct {
+	Fixed []struct {
+		Month int    `json:"month"`
+		Day   int    `json:"day"`
+		Name  string `json:"name"`
+	} `json:"fixed"`
+	Easter []struct {
+		Offset int    `json:"offset"` // Дней после Пасхи
+		Name   string `json:"name"`
+	} `json:"easter"`
+}
+
+// UA возвращает праздники Украины за год в порядке дат файла: сначала фиксированные, затем от Пасхи
+func UA(year int) ([]Holiday, error) {
+	if year < 1900 || year > 2099 {
+		return nil, fmt.Errorf("year %d is out of supported range 1900-2099", year)
+	}
+
+	var cal calendar
+	if err := json.Unmarshal(uaJSON, &cal); err != nil {
+		return nil, fmt.Errorf("failed to parse holidays: %w", err)
+	}
+
+	list := make([]Holiday, 0, len(cal.Fixed)+len(cal.Easter))
+	for _, h := range cal.Fixed {
+		list = append(list, Holiday{
+			Name:  h.Name,
+			Date:  time.Date(year, time.Month(h.Month), h.Day, 0, 0, 0, 0, time.UTC),
+			Fixed: true,
+		})
+	}
+	easter := OrthodoxEaster(year)
+	for _, h := range cal.Easter {
+		list = append(list, Holiday{Name: h.Name, Date: easter.AddDate(0, 0, h.Offset)})
+	}
+	return list, nil
+}
+
+// OrthodoxEaster — дата православной Пасхи по григорианскому календарю.
+// Алгоритм Миуса для юлианского календаря плюс 13 дней разницы, верной для 1900–2099 годов
+func OrthodoxEaster(year int) time.Time {
+	a := year % 4
+	b := year % 7
+	c := year % 19
+	d := (19*c + 15) % 30
+	e := (2*a + 4*b - d + 34) % 7
+	month := (d + e + 114) / 31
+	day := (d+e+114)%31 + 1
+	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC).AddDate(0, 0, 13)
+}
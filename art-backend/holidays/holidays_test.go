@@ -0,0 +1,169 @@
+package holidays_test
+
+import (
+	"art/holidays"
+	"art/models"
+	"testing"
+	"time"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestUA(t *testing.T) {
+	want := []holidays.Holiday{
+		{Name: "Новий рік", Date: date(2026, 1, 1), Fixed: true},
+		{Name: "Міжнародний жіночий день", Date: date(2026, 3, 8), Fixed: true},
+		{Name: "День праці", Date: date(2026, 5, 1), Fixed: true},
+		{Name: "День пам'яті та перемоги над нацизмом", Date: date(2026, 5, 8), Fixed: true},
+		{Name: "День Конституції України", Date: date(2026, 6, 28), Fixed: true},
+		{Name: "День Української Державності", Date: date(2026, 7, 15), Fixed: true},
+		{Name: "День Незалежності України", Date: date(2026, 8, 24), Fixed: true},
+		{Name: "День захисників і захисниць України", Date: date(2026, 10, 1), Fixed: true},
+		{Name: "Різдво Христове", Date: date(2026, 12, 25), Fixed: true},
+		{Name: "Великдень", Date: date(2026, 4, 12)},
+		{Name: "Трійця", Date: date(2026, 5, 31)},
+	}
+
+	got, err := holidays.UA(2026)
+	if err != nil {
+		t.Fatalf("UA(2026) error: %v", err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("UA(2026) returned %d holidays, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i].Name != want[i].Name || !got[i].Date.Equal(want[i].Date) || got[i].Fixed != want[i].Fixed {
+			t.Errorf("holiday %d = %s %s fixed=%t, want %s %s fixed=%t",
+				i, got[i].Name, got[i].Date.Format(time.DateOnly), got[i].Fixed,
+				want[i].Name, want[i].Date.Format(time.DateOnly), want[i].Fixed)
+		}
+		if got[i].Date.Location() != time.UTC {
+			t.Errorf("holiday %s is not in UTC", got[i].Name)
+		}
+	}
+}
+
+func TestUAYearRange(t *testing.T) {
+	tests := []struct {
+		year    int
+		wantErr bool
+	}{
+		{year: 1899, wantErr: true},
+		{year: 1900},
+		{year: 2099},
+		{year: 2100, wantErr: true},
+	}
+	for _, tt := range tests {
+		_, err := holidays.UA(tt.year)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("UA(%d) error = %v, want error %t", tt.year, err, tt.wantErr)
+		}
+	}
+}
+
+func TestOrthodoxEaster(t *testing.T) {
+	tests := []time.Time{
+		date(2000, 4, 30),
+		date(2023, 4, 16),
+		date(2024, 5, 5),
+		date(2025, 4, 20),
+		date(2026, 4, 12),
+		date(2027, 5, 2),
+		date(2028, 4, 16),
+	}
+	for _, want := range tests {
+		got := holidays.OrthodoxEaster(want.Year())
+		if !got.Equal(want) {
+			t.Errorf("OrthodoxEaster(%d) = %s, want %s", want.Year(), got.Format(time.DateOnly), want.Format(time.DateOnly))
+		}
+		if got.Weekday() != time.Sunday {
+			t.Errorf("OrthodoxEaster(%d) = %s is not a Sunday", want.Year(), got.Format(time.DateOnly))
+		}
+	}
+}
+
+// Импорт превращает праздники с постоянной датой в ежегодные закрытия, а переходящие — в закрытия на дату года
+func holidayClosures(t *testing.T, year int) map[string]models.Closure {
+	t.Helper()
+	list, err := holidays.UA(year)
+	if err != nil {
+		t.Fatalf("UA(%d) error: %v", year, err)
+	}
+	closures := make(map[string]models.Closure, len(list))
+	for _, h := range list {
+		closures[h.Name] = models.Closure{Title: h.Name, StartDate: h.Date, EndDate: h.Date, Recurring: h.Fixed}
+	}
+	return closures
+}
+
+func TestHolidayClosuresRecurYearly(t *testing.T) {
+	closures := holidayClosures(t, 2026)
+
+	tests := []struct {
+		holiday string
+		day     time.Time
+		want    bool
+	}{
+		{holiday: "Новий рік", day: date(2026, 1, 1), want: true},
+		{holiday: "Новий рік", day: date(2027, 1, 1), want: true},
+		{holiday: "Новий рік", day: date(2026, 12, 31)},
+		{holiday: "Новий рік", day: date(2027, 1, 2)},
+		{holiday: "Різдво Христове", day: date(2031, 12, 25), want: true},
+		{holiday: "Міжнародний жіночий день", day: date(2024, 3, 8), want: true},
+		{holiday: "Великдень", day: date(2026, 4, 12), want: true},
+		{holiday: "Великдень", day: date(2027, 4, 12)},
+		{holiday: "Великдень", day: date(2027, 5, 2)},
+		{holiday: "Трійця", day: date(2026, 5, 31), want: true},
+		{holiday: "Трійця", day: date(2027, 5, 31)},
+	}
+	for _, tt := range tests {
+		closure, ok := closures[tt.holiday]
+		if !ok {
+			t.Fatalf("holiday %q is missing from the list", tt.holiday)
+		}
+		if got := closure.Covers(tt.day, 1); got != tt.want {
+			t.Errorf("%s covers %s = %t, want %t", tt.holiday, tt.day.Format(time.DateOnly), got, tt.want)
+		}
+	}
+}
+
+func TestClosureCoversRanges(t *testing.T) {
+	activityID := uint(3)
+	winter := models.Closure{StartDate: date(2026, 12, 28), EndDate: date(2027, 1, 3)}
+	newYear := models.Closure{StartDate: date(2026, 12, 31), EndDate: date(2027, 1, 2), Recurring: true}
+	summer := models.Closure{StartDate: date(2026, 6, 1), EndDate: date(2026, 8, 31), Recurring: true}
+	oneActivity := models.Closure{StartDate: date(2026, 3, 9), EndDate: date(2026, 3, 9), ActivityID: &activityID}
+
+	tests := []struct {
+		name     string
+		closure  models.Closure
+		day      time.Time
+		activity uint
+		want     bool
+	}{
+		{name: "range start", closure: winter, day: date(2026, 12, 28), want: true},
+		{name: "range across new year", closure: winter, day: date(2027, 1, 1), want: true},
+		{name: "range end is inclusive", closure: winter, day: date(2027, 1, 3), want: true},
+		{name: "before range", closure: winter, day: date(2026, 12, 27)},
+		{name: "after range", closure: winter, day: date(2027, 1, 4)},
+		{name: "one-off range does not repeat", closure: winter, day: date(2027, 12, 30)},
+		{name: "slot time is ignored", closure: winter, day: time.Date(2027, 1, 3, 23, 30, 0, 0, time.UTC), want: true},
+		{name: "recurring across new year, december", closure: newYear, day: date(2030, 12, 31), want: true},
+		{name: "recurring across new year, january", closure: newYear, day: date(2031, 1, 2), want: true},
+		{name: "recurring across new year, before", closure: newYear, day: date(2030, 12, 30)},
+		{name: "recurring across new year, after", closure: newYear, day: date(2031, 1, 3)},
+		{name: "recurring within year", closure: summer, day: date(2029, 7, 15), want: true},
+		{name: "recurring within year, end", closure: summer, day: date(2029, 8, 31), want: true},
+		{name: "recurring within year, outside", closure: summer, day: date(2029, 9, 1)},
+		{name: "recurring within year, winter", closure: summer, day: date(2029, 1, 15)},
+		{name: "activity closure", closure: oneActivity, day: date(2026, 3, 9), activity: activityID, want: true},
+		{name: "other activity", closure: oneActivity, day: date(2026, 3, 9), activity: activityID + 1},
+	}
+	for _, tt := range tests {
+		if got := tt.closure.Covers(tt.day, tt.activity); got != tt.want {
+			t.Errorf("%s: Covers(%s) = %t, want %t", tt.name, tt.day.Format(time.DateOnly), got, tt.want)
+		}
+	}
+}
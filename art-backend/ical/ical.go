@@ -0,0 +1,116 @@
+package ical
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	StatusConfirmed = "CONFIRMED"
+	StatusTentative = "TENTATIVE"
+	StatusCancelled = "CANCELLED"
+)
+
+// Event — одно занятие в календаре. UID должен быть стабильным: по нему календари
+// находят уже добавленное событие и обновляют или отменяют его
+type Event struct {
+	UID          string
+	Summary      string
+	Description  string
+	Location     string
+	Start        time.Time
+	End          time.Time
+	Status       string
+	LastModified time.Time
+	Sequence     int // Растёт при каждом изменении события
+}
+
+type Calendar struct {
+	Name   string
+	Events []Event
+}
+
+// Write пишет календарь в формате iCalendar (RFC 5545)
+func (cal Calendar) Write(w io.Writer) error {
+	lw := &lineWriter{w: w}
+
+	lw.line("BEGIN:VCALENDAR")
+	lw.line("VERSION:2.0")
+	lw.line("PRODID:-//Art Studio//Schedule//UK")
+	lw.line("CALSCALE:GREGORIAN")
+	lw.line("METHOD:PUBLISH")
+	lw.line("X-WR-CALNAME:" + escape(cal.Name))
+	lw.line("X-PUBLISHED-TTL:PT1H") // Подсказка клиентам, как часто перечитывать ленту
+	lw.line("REFRESH-INTERVAL;VALUE=DURATION:PT1H")
+
+	now := time.Now()
+	for _, ev := range cal.Events {
+		stamp := ev.LastModified
+		if stamp.IsZero() {
+			stamp = now
+		}
+		lw.line("BEGIN:VEVENT")
+		lw.line("UID:" + ev.UID)
+		lw.line("DTSTAMP:" + formatTime(stamp))
+		lw.line("LAST-MODIFIED:" + formatTime(stamp))
+		lw.line(fmt.Sprintf("SEQUENCE:%d", ev.Sequence))
+		lw.line("DTSTART:" + formatTime(ev.Start))
+		if !ev.End.IsZero() && ev.End.After(ev.Start) {
+			lw.line("DTEND:" + formatTime(ev.End))
+		}
+		lw.line("SUMMARY:" + escape(ev.Summary))
+		if ev.Description != "" {
+			lw.line("DESCRIPTION:" + escape(ev.Description))
+		}
+		if ev.Location != "" {
+			lw.line("LOCATION:" + escape(ev.Location))
+		}
+		if ev.Status != "" {
+			lw.line("STATUS:" + ev.Status)
+		}
+		lw.line("END:VEVENT")
+	}
+
+	lw.line("END:VCALENDAR")
+	return lw.err
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+func escape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(s)
+}
+
+// lineWriter пишет строки через CRLF и переносит длинные строки по 75 байт, не разрывая символы UTF-8
+type lineWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (lw *lineWriter) line(s string) {
+	if lw.err != nil {
+		return
+	}
+
+	var b strings.Builder
+	limit := 75
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		b.WriteString(s[:cut])
+		b.WriteString("\r\n ")
+		s = s[cut:]
+		limit = 74 // Продолжение начинается с пробела, он тоже считается
+	}
+	b.WriteString(s)
+	b.WriteString("\r\n")
+
+	_, lw.err = io.WriteString(lw.w, b.String())
+}
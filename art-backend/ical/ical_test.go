@@ -0,0 +1,135 @@
+package ical
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files in testdata")
+
+func TestCalendarWriteGolden(t *testing.T) {
+	start := time.Date(2026, 3, 14, 10, 0, 0, 0, time.UTC)
+	modified := time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC)
+
+	cal := Calendar{
+		Name: "Малювання, 5+; група \\ субота",
+		Events: []Event{
+			{
+				UID:          "slot-42@art-studio",
+				Summary:      "Малювання аквареллю",
+				Description:  "Візьміть фартух, пензлі та гарний настрій.\nЗаняття триває годину, батьки можуть зачекати в холі студії",
+				Location:     "вул. Хрещатик, 1; 2 поверх",
+				Start:        start,
+				End:          start.Add(time.Hour),
+				Status:       StatusConfirmed,
+				LastModified: modified,
+				Sequence:     3,
+			},
+			{
+				UID:          "record-7@art-studio",
+				Summary:      "Ліплення",
+				Start:        start.Add(24 * time.Hour),
+				Status:       StatusCancelled,
+				LastModified: modified,
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := cal.Write(&buf); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	golden := filepath.Join("testdata", "calendar.ics")
+	if *update {
+		if err := os.WriteFile(golden, buf.Bytes(), 0o644); err != nil {
+			t.Fatalf("update golden: %v", err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("read golden: %v", err)
+	}
+	if !bytes.Equal(buf.Bytes(), want) {
+		t.Errorf("calendar does not match %s\ngot:\n%s\nwant:\n%s", golden, buf.String(), want)
+	}
+}
+
+func TestLineFolding(t *testing.T) {
+	tests := []struct {
+		name  string
+		line  string
+		lines int
+	}{
+		{name: "short", line: "SUMMARY:Ліплення", lines: 1},
+		{name: "exactly 75 bytes", line: strings.Repeat("a", 75), lines: 1},
+		{name: "76 bytes", line: strings.Repeat("a", 76), lines: 2},
+		{name: "continuation holds 74 bytes", line: strings.Repeat("a", 75+74), lines: 2},
+		{name: "continuation overflow", line: strings.Repeat("a", 75+75), lines: 3},
+		{name: "multibyte never split", line: "DESCRIPTION:" + strings.Repeat("ї", 100), lines: 3},
+		{name: "mixed widths", line: "X:" + strings.Repeat("aї€", 40), lines: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			lw := &lineWriter{w: &buf}
+			lw.line(tt.line)
+			if lw.err != nil {
+				t.Fatalf("write: %v", lw.err)
+			}
+
+			out := buf.String()
+			if !strings.HasSuffix(out, "\r\n") {
+				t.Fatalf("line is not terminated with CRLF: %q", out)
+			}
+			physical := strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n")
+			if len(physical) != tt.lines {
+				t.Errorf("got %d physical lines, want %d: %q", len(physical), tt.lines, physical)
+			}
+			for i, l := range physical {
+				if len(l) > 75 {
+					t.Errorf("line %d is %d bytes long", i, len(l))
+				}
+				if i > 0 && !strings.HasPrefix(l, " ") {
+					t.Errorf("continuation line %d does not start with a space: %q", i, l)
+				}
+				if !utf8.ValidString(l) {
+					t.Errorf("line %d splits a UTF-8 character: %q", i, l)
+				}
+			}
+
+			if unfolded := strings.ReplaceAll(strings.TrimSuffix(out, "\r\n"), "\r\n ", ""); unfolded != tt.line {
+				t.Errorf("unfolded line = %q, want %q", unfolded, tt.line)
+			}
+		})
+	}
+}
+
+func TestEscape(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "Малювання", want: "Малювання"},
+		{in: "a,b", want: `a\,b`},
+		{in: "a;b", want: `a\;b`},
+		{in: `a\b`, want: `a\\b`},
+		{in: "a\nb", want: `a\nb`},
+		{in: "a\r\nb", want: `a\nb`},
+		{in: `\,;`, want: `\\\,\;`},
+		{in: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		if got := escape(tt.in); got != tt.want {
+			t.Errorf("escape(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -40,6 +40,13 @@ func main() {
 		log.Fatal().Err(err).Msg("Redis not initialized")
 	}
 
+	jobs, err := handlers.NewScheduler() // Фоновые задачи: продление расписания и автозапись по абонементам
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to configure scheduler")
+	}
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	jobs.Start(jobsCtx)
+
 	router := gin.Default()
 
 	rest_port := os.Getenv("REST_PORT")
@@ -59,9 +66,9 @@ func main() {
 			"http://localhost:8080",
 			"http://127.0.0.1:3000",
 		},
-		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", middleware.IdempotencyHeader},
+		ExposeHeaders:    []string{"Content-Length", "Content-Disposition", "Idempotent-Replayed"}, // Content-Disposition — имя файла выгрузки
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
@@ -85,12 +92,17 @@ func main() {
 
 	router.GET("/activity/:activity_id/slots", handlers.GetActivitySlots())
 
+	// Ленты .ics для календарей: публичное расписание занятия и личная лента клиента по секретной ссылке
+	router.GET("/activities/:id/calendar.ics", handlers.ActivityCalendarFeed())
+	router.GET("/calendar/:token", handlers.ClientCalendarFeed())
+
 	router.GET("/subscriptions/types", handlers.GetAllSubTypes())
 	router.GET("/subscriptions/types/:id", handlers.GetSubTypeByID())
 
 	// Защищённые роуты с JWT
 	api := router.Group("/")
 	api.Use(middleware.AuthMiddleware())
+	api.Use(middleware.Idempotency()) // Повтор POST/PUT/DELETE с тем же Idempotency-Key отдаёт сохранённый ответ
 
 	api.GET("/admin/users", middleware.OwnerOnly(), handlers.GetAllUsers())
 
@@ -101,6 +113,10 @@ func main() {
 	api.GET("/records/:id", handlers.GetRecordByID())
 	api.GET("/records", handlers.GetAllRecords())
 	api.DELETE("/records/:id", middleware.OwnerOnly(), handlers.DeleteRecordByID())
+	api.POST("/records/:id/reschedule", handlers.RescheduleRecord()) // Владелец — любую запись, клиент — свою в пределах окна отмены
+	api.PATCH("/records/:id/status", middleware.OwnerOnly(), handlers.UpdateRecordStatus())
+	api.GET("/records/:id/status-history", middleware.OwnerOnly(), handlers.GetRecordStatusHistory())
+	api.GET("/records/:id/receipt", handlers.GetRecordReceipt()) // PDF для клиента-владельца записи или владельца студии
 
 	api.POST("/subscriptions/types", middleware.OwnerOnly(), handlers.AddSubType())
 	api.PUT("/subscriptions/types/:id", middleware.OwnerOnly(), handlers.UpdateSubType())
@@ -108,6 +124,7 @@ func main() {
 
 	api.GET("/subscriptions/:id", handlers.GetSubscriptionByID())
 	api.GET("/subscriptions", handlers.GetAllSubscriptions())
+	api.GET("/subscriptions/:id/receipt", handlers.GetSubscriptionReceipt())
 	api.POST("/subscriptions", middleware.OwnerOnly(), handlers.AddSubscription())
 	api.PUT("/subscriptions/:id", middleware.OwnerOnly(), handlers.UpdateSubscription())
 	api.DELETE("/subscriptions/:id", middleware.OwnerOnly(), handlers.DeleteSubscription())
@@ -130,18 +147,69 @@ func main() {
 	api.PUT("/activity/:activity_id/slots/:slot_id", middleware.OwnerOnly(), handlers.UpdateSlot())
 	api.DELETE("/activity/:activity_id/slots/:slot_id", middleware.OwnerOnly(), handlers.DeleteSlot())
 
+	api.POST("/activity/:activity_id/slots/:slot_id/waitlist", handlers.JoinWaitlist()) // Лист ожидания на заполненный слот
+	api.GET("/activity/:activity_id/slots/:slot_id/waitlist", middleware.OwnerOnly(), handlers.GetSlotWaitlist())
+	api.GET("/activity/:activity_id/slots/:slot_id/roster", middleware.StaffOnly(), handlers.GetSlotRoster())
+	api.PUT("/activity/:activity_id/slots/:slot_id/attendance", middleware.StaffOnly(), handlers.MarkAttendance()) // Отметка присутствия детей на занятии
+	api.GET("/attendance", middleware.StaffOnly(), handlers.GetAttendance())
+	api.POST("/activity/:activity_id/slots/:slot_id/holds", handlers.CreateHold()) // Удержание мест на время оформления записи
+	api.GET("/client/holds", handlers.GetMyHolds())
+	api.POST("/client/holds/:id/confirm", handlers.ConfirmHold())
+	api.DELETE("/client/holds/:id", handlers.ReleaseHold())
+	api.GET("/client/calendar", handlers.GetMyCalendarFeed()) // Ссылка на личную .ics-ленту
+	api.POST("/client/calendar/reset", handlers.ResetMyCalendarFeed())
+	api.GET("/client/waitlist", handlers.GetMyWaitlist())
+	api.DELETE("/client/waitlist/:id", handlers.LeaveWaitlist())
+
 	api.POST("/admin/register", middleware.OwnerOnly(), handlers.RegisterByOwner)
+	api.POST("/admin/records", middleware.OwnerOnly(), handlers.StaffMakeRecord())                   // Запись за клиента по телефону или гостя без аккаунта
+	api.POST("/admin/users/:id/claim-code", middleware.OwnerOnly(), handlers.CreateGuestClaimCode()) // Код, по которому клиент заберёт гостевой аккаунт при регистрации
+
+	api.GET("/admin/export/records", middleware.OwnerOnly(), handlers.ExportRecords()) // Выгрузка в csv или xlsx через ?format=
+	api.GET("/admin/export/subscriptions", middleware.OwnerOnly(), handlers.ExportSubscriptions())
+	api.GET("/admin/export/users", middleware.OwnerOnly(), handlers.ExportUsers())
 
 	api.GET("/client/records", handlers.GetMyRecords())
-	api.POST("/record", handlers.MakeRecord()) // Самостоятельная запись пользователем на одно занятие
+	api.DELETE("/client/records/:id", handlers.CancelMyRecord()) // Отмена записи клиентом в пределах окна отмены
+	api.GET("/client/no-shows", handlers.GetMyNoShows())         // Неявки и блокировка самозаписи
+	api.POST("/record", handlers.MakeRecord())                   // Самостоятельная запись пользователем на одно занятие
+
+	api.POST("/client/orders", handlers.Checkout()) // Корзина: запись на несколько слотов одним заказом
+	api.GET("/client/orders", handlers.GetMyOrders())
+	api.GET("/client/orders/:id", handlers.GetMyOrderByID())
+
+	api.POST("/client/series", handlers.CreateBookingSeries()) // Запись на одно и то же занятие на несколько недель вперёд
+	api.GET("/client/series", handlers.GetMySeries())
+	api.DELETE("/client/series/:id", handlers.CancelSeries())
 
 	api.GET("/client/kids/:id", handlers.GetKidByID())
+	api.GET("/client/kids/:id/records", handlers.GetKidRecords()) // На какие занятия записывался ребёнок
+	api.GET("/client/kids/:id/attendance", handlers.GetKidAttendance())
 	api.GET("/client/kids", handlers.GetMyKids())
 	api.GET("/admin/kids", middleware.OwnerOnly(), handlers.GetAllKids())
 	api.POST("/client/kids", handlers.AddKid())
 	api.PUT("/client/kids/:id", handlers.UpdateKid())
 	api.DELETE("/client/kids/:id", handlers.DeleteKid())
 
+	api.GET("/admin/promo-codes", middleware.OwnerOnly(), handlers.GetPromoCodes())
+	api.POST("/admin/promo-codes", middleware.OwnerOnly(), handlers.CreatePromoCode())
+	api.PUT("/admin/promo-codes/:id", middleware.OwnerOnly(), handlers.UpdatePromoCode())
+	api.DELETE("/admin/promo-codes/:id", middleware.OwnerOnly(), handlers.DeletePromoCode())
+	api.GET("/admin/promo-codes/:id/redemptions", middleware.OwnerOnly(), handlers.GetPromoRedemptions())
+
+	api.GET("/admin/settings", middleware.OwnerOnly(), handlers.GetStudioSettings())
+	api.PUT("/admin/settings", middleware.OwnerOnly(), handlers.UpdateStudioSettings())
+
+	api.GET("/admin/closures", middleware.OwnerOnly(), handlers.GetClosures()) // Праздники и каникулы, в которые слоты не генерируются
+	api.POST("/admin/closures", middleware.OwnerOnly(), handlers.CreateClosure())
+	api.POST("/admin/closures/holidays", middleware.OwnerOnly(), handlers.ImportHolidays()) // Импорт государственных праздников Украины за ?year=
+	api.PUT("/admin/closures/:id", middleware.OwnerOnly(), handlers.UpdateClosure())
+	api.DELETE("/admin/closures/:id", middleware.OwnerOnly(), handlers.DeleteClosure())
+	api.GET("/admin/closures/:id/conflicts", middleware.OwnerOnly(), handlers.GetClosureConflicts()) // Уже созданные слоты в закрытые дни
+
+	api.GET("/admin/jobs/runs", middleware.OwnerOnly(), handlers.GetJobRuns())   // История запусков фоновых задач
+	api.POST("/admin/jobs/:name/run", middleware.OwnerOnly(), handlers.RunJob()) // Ручной запуск задачи в фоне
+
 	api.GET("/admin/errors", middleware.OwnerOnly(), handlers.GetAllErrors())
 	api.DELETE("/admin/errors:id", middleware.OwnerOnly(), handlers.DeleteError())
 
@@ -166,6 +234,10 @@ func main() {
 		log.Info().Msg("HTTP server stopped")
 	}
 
+	stopJobs() // Задачи прерываются, ждём, пока выполняющиеся сохранят историю запуска
+	jobs.Wait()
+	log.Info().Msg("Scheduler stopped")
+
 	if err := redisClient.Close(); err != nil { // Закрытие соединения с Redis
 		log.Error().Err(err).Msg("Failed to close Redis connection")
 	} else {
@@ -73,3 +73,17 @@ func OwnerOnly() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// Владелец или инструктор: работа с группой на занятии
+func StaffOnly() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := c.GetString("role")
+		if role != "owner" && role != "instructor" {
+			log.Error().Msg("Access denied: staff only")
+			c.JSON(http.StatusForbidden, gin.H{"error": "Access denied: staff only"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,166 @@
+package middleware
+
+import (
+	"art/database"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	IdempotencyHeader = "Idempotency-Key"
+	idempotencyTTL    = 24 * time.Hour
+	idempotencyLock   = time.Minute // Сколько держится отметка «запрос выполняется», если обработчик упал
+)
+
+// Сохранённый результат запроса с ключом идемпотентности
+type idempotentResponse struct {
+	RequestHash string `json:"request_hash"`
+	Done        bool   `json:"done"`
+	Status      int    `json:"status"`
+	ContentType string `json:"content_type"`
+	Body        []byte `json:"body"`
+}
+
+type capturingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *capturingWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *capturingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Idempotency отдаёт сохранённый ответ на повтор изменяющего запроса с тем же Idempotency-Key.
+// Ключ привязан к пользователю и хэшу запроса: тот же ключ с другим телом — 422
+func Idempotency() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyHeader)
+		if key == "" || c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+			c.Next()
+			return
+		}
+
+		if len(key) > 255 {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key is too long"})
+			return
+		}
+
+		redisClient, err := database.GetRedis()
+		if err != nil || redisClient == nil {
+			log.Warn().Err(err).Msg("Redis not available, idempotency key ignored")
+			c.Next()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		hash := sha256.New()
+		hash.Write([]byte(c.Request.Method + " " + c.Request.URL.RequestURI() + "\n"))
+		hash.Write(body)
+		requestHash := hex.EncodeToString(hash.Sum(nil))
+
+		ctx := c.Request.Context()
+		redisKey := fmt.Sprintf("idempotency:%s:%s", c.GetString("phone_number"), key)
+
+		pending, _ := json.Marshal(idempotentResponse{RequestHash: requestHash})
+		acquired, err := redisClient.SetNX(ctx, redisKey, pending, idempotencyLock).Result()
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to store idempotency key")
+			c.Next()
+			return
+		}
+
+		if !acquired {
+			replayIdempotent(c, redisClient, redisKey, requestHash)
+			return
+		}
+
+		writer := &capturingWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+
+		c.Next()
+
+		status := writer.Status()
+		if status >= http.StatusInternalServerError {
+			// Сбой сервера не запоминается, клиент может повторить запрос с тем же ключом
+			redisClient.Del(ctx, redisKey)
+			return
+		}
+
+		stored, err := json.Marshal(idempotentResponse{
+			RequestHash: requestHash,
+			Done:        true,
+			Status:      status,
+			ContentType: writer.Header().Get("Content-Type"),
+			Body:        writer.body.Bytes(),
+		})
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to serialize idempotent response")
+			return
+		}
+		if err := redisClient.Set(ctx, redisKey, stored, idempotencyTTL).Err(); err != nil {
+			log.Error().Err(err).Msg("Failed to save idempotent response")
+		}
+	}
+}
+
+func replayIdempotent(c *gin.Context, redisClient *redis.Client, redisKey, requestHash string) {
+	raw, err := redisClient.Get(c.Request.Context(), redisKey).Bytes()
+	if errors.Is(err, redis.Nil) {
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "Request with this Idempotency-Key is being processed, retry later"})
+		return
+	}
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to read idempotency key")
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to check Idempotency-Key"})
+		return
+	}
+
+	var stored idempotentResponse
+	if err := json.Unmarshal(raw, &stored); err != nil {
+		log.Error().Err(err).Msg("Failed to unmarshal idempotent response")
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to check Idempotency-Key"})
+		return
+	}
+
+	if stored.RequestHash != requestHash {
+		c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"error": "Idempotency-Key was already used with a different request"})
+		return
+	}
+
+	if !stored.Done {
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "Request with this Idempotency-Key is being processed, retry later"})
+		return
+	}
+
+	log.Info().Str("key", redisKey).Msg("Replaying idempotent response")
+	c.Header("Idempotent-Replayed", "true")
+	if stored.ContentType != "" {
+		c.Header("Content-Type", stored.ContentType)
+	}
+	c.Status(stored.Status)
+	c.Writer.Write(stored.Body)
+	c.Abort()
+}
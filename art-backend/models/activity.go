@@ -8,15 +8,17 @@ import (
 )
 
 type Activity struct {
-	ID             uint           `json:"id" gorm:"primaryKey;autoIncrement"`
-	Name           string         `json:"name" gorm:"not null;unique;size:100"`
-	Description    string         `json:"description" gorm:"not null;type:text"`
-	Images         ActivityImage  `json:"images" gorm:"type:jsonb;not null"`
-	Price          uint           `json:"price" gorm:"type:integer;not null"`
-	Duration       uint           `json:"duration" gorm:"type:integer;not null"`
-	AvailableSlots int            `json:"available_slots" gorm:"not null"`
-	Slots          []ActivitySlot `json:"slots" gorm:"foreignKey:ActivityID;constraint:OnDelete:CASCADE;"`
-	IsRegular      bool           `json:"is_regular" gorm:"column:is_regular;not null;default:false"`
+	ID             uint              `json:"id" gorm:"primaryKey;autoIncrement"`
+	Name           string            `json:"name" gorm:"not null;unique;size:100"`
+	Description    string            `json:"description" gorm:"not null;type:text"`
+	Images         ActivityImage     `json:"images" gorm:"type:jsonb;not null"`
+	Price          uint              `json:"price" gorm:"type:integer;not null"`
+	Duration       uint              `json:"duration" gorm:"type:integer;not null"`
+	AvailableSlots int               `json:"available_slots" gorm:"not null"`
+	Slots          []ActivitySlot    `json:"slots" gorm:"foreignKey:ActivityID;constraint:OnDelete:CASCADE;"`
+	IsRegular      bool              `json:"is_regular" gorm:"column:is_regular;not null;default:false"`
+	AgeRange       `gorm:"embedded"` // min_age / max_age
+	WorkingHours   *WorkingHours     `json:"working_hours" gorm:"type:jsonb"` // Пустое — часы студии
 
 	CreatedAt time.Time  `json:"-"`
 	UpdatedAt time.Time  `json:"-"`
@@ -0,0 +1,34 @@
+package models
+
+import "fmt"
+
+// Возрастные ограничения занятия или шаблона, пустая граница — без ограничения
+type AgeRange struct {
+	MinAge *int `json:"min_age" binding:"omitempty,min=0,max=18"`
+	MaxAge *int `json:"max_age" binding:"omitempty,min=0,max=18"`
+}
+
+func (r AgeRange) IsSet() bool {
+	return r.MinAge != nil || r.MaxAge != nil
+}
+
+func (r AgeRange) Valid() bool {
+	return r.MinAge == nil || r.MaxAge == nil || *r.MinAge <= *r.MaxAge
+}
+
+func (r AgeRange) Allows(age int) bool {
+	return (r.MinAge == nil || age >= *r.MinAge) && (r.MaxAge == nil || age <= *r.MaxAge)
+}
+
+// String описывает ограничение так, как его пишут в названиях занятий: "5+", "3-6", "до 7"
+func (r AgeRange) String() string {
+	switch {
+	case r.MinAge != nil && r.MaxAge != nil:
+		return fmt.Sprintf("%d-%d", *r.MinAge, *r.MaxAge)
+	case r.MinAge != nil:
+		return fmt.Sprintf("%d+", *r.MinAge)
+	case r.MaxAge != nil:
+		return fmt.Sprintf("до %d", *r.MaxAge)
+	}
+	return "без обмежень"
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	AttendancePresent = "present"
+	AttendanceAbsent  = "absent"
+	AttendanceLate    = "late"
+	AttendanceExcused = "excused"
+)
+
+// Отметка посещения одного ребёнка из записи на конкретном слоте
+type Attendance struct {
+	gorm.Model
+	RecordKidID uint      `json:"record_kid_id" gorm:"not null"`
+	RecordID    uint      `json:"record_id" gorm:"not null"`
+	SlotID      uint      `json:"slot_id" gorm:"not null;index"`
+	UserKidID   *uint     `json:"user_kid_id,omitempty"`
+	SubKidID    *uint     `json:"sub_kid_id,omitempty"`
+	SlotStart   time.Time `json:"slot_start" gorm:"not null"` // Копия начала слота для выборок по датам
+	Status      string    `json:"status" gorm:"type:varchar(20);not null"`
+	Note        string    `json:"note" gorm:"type:text;not null;default:''"`
+	MarkedAt    time.Time `json:"marked_at" gorm:"not null"`
+	MarkedBy    uint      `json:"marked_by" gorm:"not null"`
+}
+
+// Пришёл ли ребёнок на занятие (опоздание тоже считается посещением)
+func (a Attendance) Attended() bool {
+	return a.Status == AttendancePresent || a.Status == AttendanceLate
+}
+
+type AttendanceMark struct {
+	RecordKidID uint   `json:"record_kid_id" binding:"required"`
+	Status      string `json:"status" binding:"required,oneof=present absent late excused"`
+	Note        string `json:"note" binding:"max=500"`
+}
+
+type AttendanceRequest struct {
+	Marks []AttendanceMark `json:"marks" binding:"required,min=1,dive"`
+}
+
+// Строка списка группы на слот с текущей отметкой посещения
+type RosterEntry struct {
+	RecordKidID uint       `json:"record_kid_id"`
+	RecordID    uint       `json:"record_id"`
+	Name        string     `json:"name"`
+	Age         int        `json:"age"`
+	Gender      string     `json:"gender"`
+	UserKidID   *uint      `json:"user_kid_id,omitempty"`
+	SubKidID    *uint      `json:"sub_kid_id,omitempty"`
+	ParentName  string     `json:"parent_name"`
+	PhoneNumber string     `json:"phone_number"`
+	Status      *string    `json:"status"`
+	MarkedAt    *time.Time `json:"marked_at"`
+	MarkedBy    *uint      `json:"marked_by"`
+}
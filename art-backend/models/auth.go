@@ -17,12 +17,13 @@ type RegisterClient struct {
 	PhoneNumber string `json:"phone_number" binding:"required"`
 	Name        string `json:"name" binding:"required"`
 	Surname     string `json:"surname" binding:"required"`
+	ClaimCode   string `json:"claim_code"` // Код от студии, если на этот телефон уже заведён гостевой аккаунт
 }
 
 type RegisterOwner struct {
 	Username    string `json:"username" binding:"required,min=3,max=50"`
 	Password    string `json:"password" binding:"required,min=6"`
-	Role        string `json:"role" binding:"required,oneof=client owner"`
+	Role        string `json:"role" binding:"required,oneof=client owner instructor"`
 	PhoneNumber string `json:"phone_number" binding:"required"`
 	Name        string `json:"name" binding:"required"`
 	Surname     string `json:"surname" binding:"required"`
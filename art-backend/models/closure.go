@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	ClosureSourceManual  = "manual"
+	ClosureSourceHoliday = "holiday" // Импортирован из встроенного списка праздников
+)
+
+// Дни, когда студия или одно занятие не работает. Генерация расписания на них слоты не создаёт
+type Closure struct {
+	gorm.Model
+	Title      string    `json:"title" gorm:"type:varchar(100);not null"`
+	StartDate  time.Time `json:"start_date" gorm:"type:date;not null"`
+	EndDate    time.Time `json:"end_date" gorm:"type:date;not null"`      // Включительно
+	ActivityID *uint     `json:"activity_id"`                             // Пустой — закрыта вся студия
+	Recurring  bool      `json:"recurring" gorm:"not null;default:false"` // Повторяется каждый год в те же числа, год дат не важен
+	Source     string    `json:"source" gorm:"type:varchar(20);not null;default:'manual'"`
+}
+
+// Covers проверяет, закрыт ли день для занятия. day — дата занятия, время не учитывается
+func (c Closure) Covers(day time.Time, activityID uint) bool {
+	if c.ActivityID != nil && *c.ActivityID != activityID {
+		return false
+	}
+	day = time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
+	if !c.Recurring {
+		return !day.Before(c.StartDate) && !day.After(c.EndDate)
+	}
+
+	// Повторяющийся период сравнивается по месяцу и дню; период через Новый год переходит через 31.12
+	md := monthDay(day)
+	from, to := monthDay(c.StartDate), monthDay(c.EndDate)
+	if c.StartDate.Year() == c.EndDate.Year() {
+		return md >= from && md <= to
+	}
+	return md >= from || md <= to
+}
+
+func monthDay(t time.Time) int {
+	return int(t.Month())*100 + t.Day()
+}
+
+type ClosureInput struct {
+	Title      string `json:"title" binding:"required,max=100"`
+	StartDate  string `json:"start_date" binding:"required"` // YYYY-MM-DD
+	EndDate    string `json:"end_date"`                      // Пустое — один день
+	ActivityID *uint  `json:"activity_id"`
+	Recurring  bool   `json:"recurring"`
+}
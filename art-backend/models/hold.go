@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	HoldStatusActive    = "active"
+	HoldStatusConfirmed = "confirmed"
+	HoldStatusReleased  = "released"
+)
+
+// Временное удержание мест на слоте на время оформления записи. Истёкший холд перестаёт учитываться сам
+type SlotHold struct {
+	gorm.Model
+	SlotID       uint      `json:"slot_id" gorm:"not null;index"`
+	UserID       uint      `json:"user_id" gorm:"not null;index"`
+	NumberOfKids uint      `json:"number_of_kids" gorm:"not null"`
+	Kids         KidList   `json:"kids" gorm:"type:jsonb;not null"`
+	ExpiresAt    time.Time `json:"expires_at" gorm:"not null"`
+	Status       string    `json:"status" gorm:"type:varchar(20);not null;default:'active'"`
+	RecordID     *uint     `json:"record_id"`
+}
+
+func (h SlotHold) IsActive(now time.Time) bool {
+	return h.Status == HoldStatusActive && h.ExpiresAt.After(now)
+}
+
+type HoldRequest struct {
+	NumberOfKids uint   `json:"number_of_kids" binding:"omitempty,gte=1"`
+	Kids         []Kid  `json:"kids" binding:"omitempty,dive"`
+	UserKidIDs   []uint `json:"user_kid_ids" binding:"omitempty,dive,gte=1"`
+}
+
+type HoldResponse struct {
+	SlotHold
+	SecondsLeft int `json:"seconds_left"`
+}
+
+func ToHoldResponse(hold SlotHold, now time.Time) HoldResponse {
+	left := 0
+	if hold.IsActive(now) {
+		left = int(hold.ExpiresAt.Sub(now).Seconds())
+	}
+	return HoldResponse{SlotHold: hold, SecondsLeft: left}
+}
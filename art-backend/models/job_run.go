@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+const (
+	JobRunRunning = "running"
+	JobRunSuccess = "success"
+	JobRunFailed  = "failed"
+)
+
+// Один запуск фоновой задачи планировщика
+type JobRun struct {
+	ID           uint       `json:"id" gorm:"primaryKey;autoIncrement"`
+	JobName      string     `json:"job_name" gorm:"type:varchar(50);not null;index"`
+	Trigger      string     `json:"trigger" gorm:"type:varchar(20);not null"` // schedule или manual
+	Instance     string     `json:"instance" gorm:"type:varchar(100);not null"`
+	Status       string     `json:"status" gorm:"type:varchar(20);not null"`
+	StartedAt    time.Time  `json:"started_at" gorm:"not null"`
+	FinishedAt   *time.Time `json:"finished_at"`
+	SlotsCreated int        `json:"slots_created" gorm:"not null;default:0"`
+	ErrorsRaised int        `json:"errors_raised" gorm:"not null;default:0"` // Сколько StudioError сохранила задача
+	Error        string     `json:"error,omitempty" gorm:"type:text;not null;default:''"`
+}
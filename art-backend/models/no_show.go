@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	NoShowReasonAbsent     = "absent"
+	NoShowReasonLateCancel = "late_cancel"
+)
+
+// Неявка клиента: ребёнок не пришёл или запись отменена позже окна отмены
+type NoShowEvent struct {
+	gorm.Model
+	UserID     uint      `json:"user_id" gorm:"not null;index"`
+	RecordID   uint      `json:"record_id" gorm:"not null"`
+	SlotID     uint      `json:"slot_id" gorm:"not null"`
+	Reason     string    `json:"reason" gorm:"type:varchar(20);not null"`
+	OccurredAt time.Time `json:"occurred_at" gorm:"not null"`
+}
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Заказ из корзины: несколько разовых записей, оформленных одной транзакцией
+type Order struct {
+	gorm.Model
+	UserID      uint     `json:"user_id" gorm:"not null;index"`
+	PhoneNumber string   `json:"phone_number" gorm:"type:varchar(15);not null"`
+	ParentName  string   `json:"parent_name" gorm:"type:text"`
+	TotalPrice  uint     `json:"total_price" gorm:"type:real;not null"` // Сумма цен всех записей заказа
+	Records     []Record `json:"-" gorm:"foreignKey:OrderID"`
+}
+
+// Одна позиция корзины: слот занятия и дети, которых на него записывают
+type OrderItemRequest struct {
+	ActivityID   uint   `json:"activity_id" binding:"required"`
+	SlotID       uint   `json:"slot_id" binding:"required"`
+	NumberOfKids uint   `json:"number_of_kids" binding:"omitempty,gte=1"`
+	Kids         []Kid  `json:"kids" binding:"omitempty,dive"`
+	UserKidIDs   []uint `json:"user_kid_ids" binding:"omitempty,dive,gte=1"`
+}
+
+type OrderRequest struct {
+	Items []OrderItemRequest `json:"items" binding:"required,min=1,max=10,dive"`
+}
+
+type OrderResponse struct {
+	ID          uint             `json:"id"`
+	CreatedAt   time.Time        `json:"created_at"`
+	PhoneNumber string           `json:"phone_number"`
+	ParentName  string           `json:"parent_name"`
+	TotalPrice  uint             `json:"total_price"`
+	Records     []RecordResponse `json:"records"`
+}
+
+func ToOrderResponse(order Order) OrderResponse {
+	records := make([]RecordResponse, len(order.Records))
+	for i, record := range order.Records {
+		records[i] = ToRecordResponse(record)
+	}
+
+	return OrderResponse{
+		ID:          order.ID,
+		CreatedAt:   order.CreatedAt,
+		PhoneNumber: order.PhoneNumber,
+		ParentName:  order.ParentName,
+		TotalPrice:  order.TotalPrice,
+		Records:     records,
+	}
+}
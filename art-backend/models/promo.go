@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	PromoTypePercent = "percent"
+	PromoTypeFixed   = "fixed"
+)
+
+// Промокод студии. Ограничения по занятию и типу абонемента необязательны: пустое — действует везде
+type PromoCode struct {
+	gorm.Model
+	Code               string     `json:"code" gorm:"type:varchar(50);not null"`
+	DiscountType       string     `json:"discount_type" gorm:"type:varchar(10);not null"`
+	Value              uint       `json:"value" gorm:"not null"` // Процент или сумма в гривнях
+	ValidFrom          *time.Time `json:"valid_from"`
+	ValidTo            *time.Time `json:"valid_to"`
+	MaxUses            int        `json:"max_uses" gorm:"not null;default:0"`       // Всего применений, 0 — без ограничения
+	PerUserLimit       int        `json:"per_user_limit" gorm:"not null;default:0"` // Применений одним клиентом, 0 — без ограничения
+	UsesCount          int        `json:"uses_count" gorm:"not null;default:0"`
+	ActivityID         *uint      `json:"activity_id"`
+	SubscriptionTypeID *uint      `json:"subscription_type_id"`
+	IsActive           bool       `json:"is_active" gorm:"not null;default:true"`
+}
+
+// Применение промокода к записи или абонементу
+type PromoRedemption struct {
+	ID             uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	PromoCodeID    uint      `json:"promo_code_id" gorm:"not null;index"`
+	UserID         uint      `json:"user_id" gorm:"not null;index"`
+	RecordID       *uint     `json:"record_id"`
+	SubscriptionID *uint     `json:"subscription_id"`
+	Discount       uint      `json:"discount" gorm:"not null"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+type PromoCodeInput struct {
+	Code               string     `json:"code" binding:"required,min=3,max=50,alphanum"`
+	DiscountType       string     `json:"discount_type" binding:"required,oneof=percent fixed"`
+	Value              uint       `json:"value" binding:"required,gte=1"`
+	ValidFrom          *time.Time `json:"valid_from"`
+	ValidTo            *time.Time `json:"valid_to"`
+	MaxUses            int        `json:"max_uses" binding:"omitempty,min=0"`
+	PerUserLimit       int        `json:"per_user_limit" binding:"omitempty,min=0"`
+	ActivityID         *uint      `json:"activity_id"`
+	SubscriptionTypeID *uint      `json:"subscription_type_id"`
+	IsActive           *bool      `json:"is_active"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// Квитанция об оплате разовой записи или абонемента. Номер выдаётся один раз
+// и при повторном скачивании не меняется
+type Receipt struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	Number         string    `json:"number" gorm:"type:varchar(20);not null;unique"`
+	Year           int       `json:"year" gorm:"not null"`
+	Seq            int       `json:"seq" gorm:"not null"`
+	RecordID       *uint     `json:"record_id" gorm:"unique"`
+	SubscriptionID *uint     `json:"subscription_id" gorm:"unique"`
+	Total          uint      `json:"total" gorm:"type:integer;not null"`
+	IssuedAt       time.Time `json:"issued_at" gorm:"not null"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+type ReceiptCounter struct {
+	Year       int `gorm:"primaryKey;autoIncrement:false"`
+	LastNumber int `gorm:"not null;default:0"`
+}
+
+// Номер вида 2026-000123
+func ReceiptNumber(year, seq int) string {
+	return fmt.Sprintf("%d-%06d", year, seq)
+}
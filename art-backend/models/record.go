@@ -1,6 +1,7 @@
 package models
 
 import (
+	"art/pricing"
 	"database/sql/driver"
 	"encoding/json"
 	"fmt"
@@ -14,60 +15,149 @@ type RecordDetail struct {
 	ActivityID   uint      `json:"activity_id"`
 	ActivityName string    `json:"activity_name"`
 	NumberOfKids uint      `json:"number_of_kids"`
-	Kids         []Kid     `json:"kids"`
+	Kids         []Kid     `json:"kids"` // Заполняется из record_kids при чтении, в JSONB не пишется
 	Date         time.Time `json:"date"`
 }
 
 type Record struct {
 	gorm.Model
-	UserID         uint         `json:"user_id" gorm:"not null;index"`
-	SubKidID       *uint        `json:"sub_kid_id"`
-	SubscriptionID *uint        `json:"subscription_id"`
-	SlotID         uint         `json:"slot_id" gorm:"not null;index"`
-	Details        RecordDetail `json:"details" gorm:"type:jsonb;not null"`
-	PhoneNumber    string       `json:"phone_number" gorm:"type:varchar(15);not null"`
-	ParentName     string       `json:"parent_name" gorm:"type:text"`
-	TotalPrice     uint         `json:"total_price" gorm:"type:real;not null"`
+	UserID          *uint              `json:"user_id" gorm:"index"` // Пустой у гостя, записанного владельцем без аккаунта
+	SubKidID        *uint              `json:"sub_kid_id"`
+	SubscriptionID  *uint              `json:"subscription_id"`
+	SeriesID        *uint              `json:"series_id"` // Запись создана серией еженедельных бронирований
+	OrderID         *uint              `json:"order_id"`  // Запись оформлена в составе заказа из корзины
+	SlotID          uint               `json:"slot_id" gorm:"not null;index"`
+	Details         RecordDetail       `json:"details" gorm:"type:jsonb;not null"`
+	PhoneNumber     string             `json:"phone_number" gorm:"type:varchar(15);not null"`
+	ParentName      string             `json:"parent_name" gorm:"type:text"`
+	TotalPrice      uint               `json:"total_price" gorm:"type:real;not null"`
+	PriceBreakdown  *pricing.Breakdown `json:"price_breakdown" gorm:"type:jsonb"`           // Как получилась TotalPrice, пусто у записей по абонементу
+	VisitCounted    bool               `json:"visit_counted" gorm:"not null;default:false"` // Списан ли за запись визит абонемента
+	Status          string             `json:"status" gorm:"type:varchar(30);not null;default:'confirmed'"`
+	StatusReason    string             `json:"status_reason" gorm:"type:text;not null;default:''"`
+	StatusChangedAt *time.Time         `json:"status_changed_at"`
+	CreatedByStaff  bool               `json:"created_by_staff" gorm:"not null;default:false"` // Запись оформил сотрудник за клиента
+	CreatedBy       *uint              `json:"created_by"`
+	Kids            []RecordKid        `json:"-" gorm:"foreignKey:RecordID"`
+}
+
+// Ребёнок, записанный на занятие. Снимок данных на момент записи со ссылкой на профиль или абонемент
+type RecordKid struct {
+	gorm.Model
+	RecordID  uint   `json:"-" gorm:"not null;index"`
+	SlotID    uint   `json:"-" gorm:"not null"`
+	UserKidID *uint  `json:"user_kid_id,omitempty" gorm:"index"`
+	SubKidID  *uint  `json:"sub_kid_id,omitempty" gorm:"index"`
+	Name      string `json:"name" gorm:"type:varchar(100);not null"`
+	Age       int    `json:"age" gorm:"not null"`
+	Gender    string `json:"gender" gorm:"type:varchar(20);not null"`
+	Active    bool   `json:"-" gorm:"not null;default:true"` // false у детей отменённой записи
+}
+
+func NewRecordKids(slotID uint, kids []Kid) []RecordKid {
+	recordKids := make([]RecordKid, len(kids))
+	for i, kid := range kids {
+		recordKids[i] = RecordKid{
+			Active:    true,
+			SlotID:    slotID,
+			UserKidID: kid.UserKidID,
+			Name:      kid.Name,
+			Age:       kid.Age,
+			Gender:    kid.Gender,
+		}
+	}
+	return recordKids
+}
+
+func (k RecordKid) ToKid() Kid {
+	return Kid{
+		Name:      k.Name,
+		Age:       k.Age,
+		Gender:    k.Gender,
+		UserKidID: k.UserKidID,
+	}
+}
+
+// FillDetailKids переносит детей из record_kids в Details, чтобы ответ API сохранял прежнюю форму
+func (r *Record) FillDetailKids() {
+	if len(r.Kids) == 0 {
+		return
+	}
+	r.Details.Kids = make([]Kid, len(r.Kids))
+	for i, kid := range r.Kids {
+		r.Details.Kids[i] = kid.ToKid()
+	}
 }
 
 // type RecordDetails []RecordDetail
 
 type Kid struct {
-	Name   string `json:"name"`
-	Age    int    `json:"age"`
-	Gender string `json:"gender"`
+	Name      string `json:"name"`
+	Age       int    `json:"age"`
+	Gender    string `json:"gender"`
+	UserKidID *uint  `json:"user_kid_id,omitempty"` // Заполнен, если ребёнок взят из профиля клиента
 }
 
 type RecordRequest struct {
-	ActivityID   uint  `json:"activity_id" binding:"required"`
-	NumberOfKids uint  `json:"number_of_kids" binding:"required,gte=1"`
-	Kids         []Kid `json:"kids" binding:"required,dive"`
-	SlotID       uint  `json:"slot_id" binding:"required"`
+	ActivityID   uint   `json:"activity_id" binding:"required"`
+	NumberOfKids uint   `json:"number_of_kids" binding:"omitempty,gte=1"`
+	Kids         []Kid  `json:"kids" binding:"omitempty,dive"`
+	UserKidIDs   []uint `json:"user_kid_ids" binding:"omitempty,dive,gte=1"` // Сохранённые дети клиента из /client/kids
+	SlotID       uint   `json:"slot_id" binding:"required"`
+	PromoCode    string `json:"promo_code" binding:"omitempty,max=50"`
+}
+
+// Запись, которую владелец оформляет за клиента по телефону или на месте.
+// Клиент ищется по user_id или телефону, иначе записывается гость по имени и телефону
+type StaffRecordRequest struct {
+	RecordRequest
+	UserID        *uint  `json:"user_id"`
+	PhoneNumber   string `json:"phone_number" binding:"omitempty,max=15"`
+	GuestName     string `json:"guest_name" binding:"omitempty,max=100"`
+	CreateAccount bool   `json:"create_account"` // Завести гостю облегчённый аккаунт клиента
+}
+
+type RescheduleRequest struct {
+	SlotID uint `json:"slot_id" binding:"required"`
 }
 
 type RecordResponse struct {
-	ID          uint         `json:"id"`
-	CreatedAt   time.Time    `json:"created_at"`
-	Details     RecordDetail `json:"details"`
-	PhoneNumber string       `json:"phone_number"`
-	ParentName  string       `json:"parent_name"`
-	TotalPrice  uint         `json:"total_price"`
+	ID              uint               `json:"id"`
+	CreatedAt       time.Time          `json:"created_at"`
+	Details         RecordDetail       `json:"details"`
+	PhoneNumber     string             `json:"phone_number"`
+	ParentName      string             `json:"parent_name"`
+	TotalPrice      uint               `json:"total_price"`
+	PriceBreakdown  *pricing.Breakdown `json:"price_breakdown,omitempty"`
+	Status          string             `json:"status"`
+	StatusReason    string             `json:"status_reason,omitempty"`
+	StatusChangedAt *time.Time         `json:"status_changed_at,omitempty"`
+	CreatedByStaff  bool               `json:"created_by_staff"`
+	OrderID         *uint              `json:"order_id,omitempty"`
 }
 
 func ToRecordResponse(record Record) RecordResponse {
+	record.FillDetailKids()
 
 	return RecordResponse{
-		ID:          record.ID,
-		CreatedAt:   record.CreatedAt,
-		Details:     record.Details,
-		TotalPrice:  record.TotalPrice,
-		PhoneNumber: record.PhoneNumber,
-		ParentName:  record.ParentName,
+		ID:              record.ID,
+		CreatedAt:       record.CreatedAt,
+		Details:         record.Details,
+		TotalPrice:      record.TotalPrice,
+		PriceBreakdown:  record.PriceBreakdown,
+		PhoneNumber:     record.PhoneNumber,
+		ParentName:      record.ParentName,
+		Status:          record.Status,
+		StatusReason:    record.StatusReason,
+		StatusChangedAt: record.StatusChangedAt,
+		CreatedByStaff:  record.CreatedByStaff,
+		OrderID:         record.OrderID,
 	}
 }
 
 // Реализация driver.Valuer (для записи)
 func (r RecordDetail) Value() (driver.Value, error) {
+	r.Kids = nil // Дети записи хранятся в record_kids
 	return json.Marshal(r)
 }
 
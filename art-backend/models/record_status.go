@@ -0,0 +1,65 @@
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+const (
+	RecordStatusPending           = "pending"
+	RecordStatusConfirmed         = "confirmed"
+	RecordStatusCancelledByClient = "cancelled_by_client"
+	RecordStatusCancelledByStudio = "cancelled_by_studio"
+	RecordStatusCompleted         = "completed"
+	RecordStatusNoShow            = "no_show"
+)
+
+// Допустимые переходы статуса записи. Отменённые записи финальны,
+// завершённое занятие и неявку можно поправить друг на друга
+var recordTransitions = map[string][]string{
+	RecordStatusPending:   {RecordStatusConfirmed, RecordStatusCancelledByClient, RecordStatusCancelledByStudio},
+	RecordStatusConfirmed: {RecordStatusCancelledByClient, RecordStatusCancelledByStudio, RecordStatusCompleted, RecordStatusNoShow},
+	RecordStatusCompleted: {RecordStatusNoShow},
+	RecordStatusNoShow:    {RecordStatusCompleted},
+}
+
+// Статусы будущих записей, которые ещё можно отменить или перенести
+var ActiveRecordStatuses = []string{RecordStatusPending, RecordStatusConfirmed}
+
+func CanTransitionRecord(from, to string) bool {
+	for _, allowed := range recordTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+func IsActiveRecordStatus(status string) bool {
+	return slices.Contains(ActiveRecordStatuses, status)
+}
+
+func IsCancelledRecordStatus(status string) bool {
+	return status == RecordStatusCancelledByClient || status == RecordStatusCancelledByStudio
+}
+
+func IsRecordStatus(status string) bool {
+	_, ok := recordTransitions[status]
+	return ok || IsCancelledRecordStatus(status)
+}
+
+// История смены статусов записи
+type RecordStatusChange struct {
+	ID         uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	RecordID   uint      `json:"record_id" gorm:"not null;index"`
+	FromStatus string    `json:"from_status" gorm:"type:varchar(30);not null"`
+	ToStatus   string    `json:"to_status" gorm:"type:varchar(30);not null"`
+	Reason     string    `json:"reason" gorm:"type:text;not null;default:''"`
+	ChangedBy  *uint     `json:"changed_by"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type RecordStatusRequest struct {
+	Status string `json:"status" binding:"required,oneof=pending confirmed cancelled_by_client cancelled_by_studio completed no_show"`
+	Reason string `json:"reason" binding:"max=500"`
+}
@@ -0,0 +1,98 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	SeriesStatusActive    = "active"
+	SeriesStatusCancelled = "cancelled"
+
+	SeriesWeekBooked  = "booked"
+	SeriesWeekSkipped = "skipped"
+)
+
+// Серия еженедельных разовых записей на занятие по шаблону расписания
+type BookingSeries struct {
+	gorm.Model
+	UserID       uint                `json:"user_id" gorm:"not null;index"`
+	ActivityID   uint                `json:"activity_id" gorm:"not null"`
+	TemplateID   uint                `json:"template_id" gorm:"not null"`
+	PhoneNumber  string              `json:"phone_number" gorm:"type:varchar(15);not null"`
+	ParentName   string              `json:"parent_name" gorm:"type:text"`
+	NumberOfKids uint                `json:"number_of_kids" gorm:"not null"`
+	Kids         KidList             `json:"kids" gorm:"type:jsonb;not null"`
+	StartDate    time.Time           `json:"start_date" gorm:"type:date;not null"` // Дата первого занятия серии
+	Weeks        int                 `json:"weeks" gorm:"not null"`
+	Status       string              `json:"status" gorm:"type:varchar(20);not null;default:'active'"`
+	WeekResults  []BookingSeriesWeek `json:"-" gorm:"foreignKey:SeriesID"`
+}
+
+// Даты всех занятий серии, по одной на неделю
+func (s BookingSeries) Dates() []time.Time {
+	dates := make([]time.Time, s.Weeks)
+	for i := range dates {
+		dates[i] = s.StartDate.AddDate(0, 0, 7*i)
+	}
+	return dates
+}
+
+type BookingSeriesWeek struct {
+	ID        uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	SeriesID  uint      `json:"series_id" gorm:"not null"`
+	SlotDate  time.Time `json:"slot_date" gorm:"type:date;not null"`
+	SlotID    *uint     `json:"slot_id"`
+	RecordID  *uint     `json:"record_id"`
+	Status    string    `json:"status" gorm:"type:varchar(20);not null"`
+	Reason    string    `json:"reason,omitempty" gorm:"type:varchar(50);not null;default:''"` // full, duplicate, in_past
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type BookingSeriesRequest struct {
+	ActivityID   uint   `json:"activity_id" binding:"required"`
+	TemplateID   uint   `json:"template_id" binding:"required"`
+	Weeks        int    `json:"weeks" binding:"required,min=1,max=52"`
+	NumberOfKids uint   `json:"number_of_kids" binding:"omitempty,gte=1"`
+	Kids         []Kid  `json:"kids" binding:"omitempty,dive"`
+	UserKidIDs   []uint `json:"user_kid_ids" binding:"omitempty,dive,gte=1"`
+}
+
+type BookingSeriesResponse struct {
+	BookingSeries
+	Booked       []BookingSeriesWeek `json:"booked"`
+	Skipped      []BookingSeriesWeek `json:"skipped"`
+	PendingDates []string            `json:"pending_dates"` // Недели, слоты для которых ещё не сгенерированы
+}
+
+func ToBookingSeriesResponse(series BookingSeries) BookingSeriesResponse {
+	resp := BookingSeriesResponse{
+		BookingSeries: series,
+		Booked:        []BookingSeriesWeek{},
+		Skipped:       []BookingSeriesWeek{},
+		PendingDates:  []string{},
+	}
+
+	processed := make(map[string]struct{}, len(series.WeekResults))
+	for _, week := range series.WeekResults {
+		processed[week.SlotDate.Format("2006-01-02")] = struct{}{}
+		if week.Status == SeriesWeekBooked {
+			resp.Booked = append(resp.Booked, week)
+		} else {
+			resp.Skipped = append(resp.Skipped, week)
+		}
+	}
+
+	if series.Status == SeriesStatusActive {
+		for _, date := range series.Dates() {
+			day := date.Format("2006-01-02")
+			if _, ok := processed[day]; !ok {
+				resp.PendingDates = append(resp.PendingDates, day)
+			}
+		}
+	}
+
+	return resp
+}
@@ -8,22 +8,34 @@ import (
 
 type ActivitySlot struct {
 	gorm.Model
-	ActivityID uint      `json:"activity_id" gorm:"index;not null"`
-	StartTime  time.Time `json:"start_time" gorm:"not null"`
-	EndTime    time.Time `json:"end_time"`
-	Capacity   int       `json:"capacity" gorm:"not null"`
-	Booked     int       `json:"booked" gorm:"not null;default:0"`
-	TemplateID *uint     `json:"template_id" gorm:"index"`
-	Source     string    `json:"source" gorm:"type:varchar(20);not null;default:'template'"`
+	ActivityID    uint      `json:"activity_id" gorm:"index;not null"`
+	StartTime     time.Time `json:"start_time" gorm:"not null"`
+	EndTime       time.Time `json:"end_time"`
+	Capacity      int       `json:"capacity" gorm:"not null"`
+	Booked        int       `json:"booked" gorm:"not null;default:0"`
+	TemplateID    *uint     `json:"template_id" gorm:"index"`
+	Source        string    `json:"source" gorm:"type:varchar(20);not null;default:'template'"`
+	PriceOverride *uint     `json:"price_override"` // Цена за ребёнка на этом слоте вместо цены занятия
+	Held          int       `json:"held" gorm:"-"`  // Места под активными холдами, считаются при чтении
+}
+
+// Свободные места с учётом записей и активных холдов
+func (s ActivitySlot) FreePlaces() int {
+	return max(s.Capacity-s.Booked-s.Held, 0)
 }
 
 type SlotInputGenerate struct {
 	DayOfWeek int    `json:"day_of_week" binding:"required"`
 	StartTime string `json:"start_time" binding:"required"`
 	Capacity  int    `json:"capacity"`
+	AgeRange
+	ValidFrom string `json:"valid_from"` // YYYY-MM-DD, пустое — без начала
+	ValidTo   string `json:"valid_to"`   // YYYY-MM-DD включительно, пустое — бессрочно
+	Season    string `json:"season" binding:"max=50"`
 }
 
 type SlotInput struct {
-	StartTimeStr string `json:"start_time" binding:"required"`
-	Capacity     int    `json:"capacity" binding:"required,min=1"`
+	StartTimeStr  string `json:"start_time" binding:"required"`
+	Capacity      int    `json:"capacity" binding:"required,min=1"`
+	PriceOverride *uint  `json:"price_override"`
 }
@@ -0,0 +1,72 @@
+package models
+
+import "time"
+
+type StudioSettings struct {
+	ID                 uint `json:"-" gorm:"primaryKey"`
+	CancelWindowHours  int  `json:"cancel_window_hours" gorm:"not null;default:12"`     // За сколько часов до начала клиент ещё может отменить запись
+	VisitsByAttendance bool `json:"visits_by_attendance" gorm:"not null;default:false"` // Визит абонемента списывается по отметке посещения, а не при записи
+
+	// Правила неявок
+	NoShowBurnsVisit   bool `json:"no_show_burns_visit" gorm:"not null;default:true"`     // Неявка по абонементу всё равно списывает визит
+	NoShowLimit        int  `json:"no_show_limit" gorm:"not null;default:3"`              // Сколько неявок за период блокируют самозапись, 0 — не блокировать
+	NoShowPeriodDays   int  `json:"no_show_period_days" gorm:"not null;default:30"`       // Окно, в котором считаются неявки
+	NoShowBlockDays    int  `json:"no_show_block_days" gorm:"not null;default:7"`         // На сколько дней блокируется самозапись
+	LateCancelAsNoShow bool `json:"late_cancel_as_no_show" gorm:"not null;default:false"` // Поздняя отмена разрешена, но считается неявкой
+
+	HoldTTLMinutes int `json:"hold_ttl_minutes" gorm:"not null;default:10"` // Сколько держатся места, пока клиент оформляет запись
+
+	WorkingHours WorkingHours `json:"working_hours" gorm:"type:jsonb;not null"` // Рабочие дни и часы, занятие может задать свои
+
+	// Правила цены разовой записи, 0 — правило выключено
+	SiblingDiscountPercent   int `json:"sibling_discount_percent" gorm:"not null;default:0"`    // Скидка на второго и следующих детей в записи
+	EarlyBirdDays            int `json:"early_bird_days" gorm:"not null;default:0"`             // За сколько дней до занятия действует ранняя запись
+	EarlyBirdDiscountPercent int `json:"early_bird_discount_percent" gorm:"not null;default:0"` // Скидка за раннюю запись
+	MemberDiscountPercent    int `json:"member_discount_percent" gorm:"not null;default:0"`     // Скидка клиентам с действующим абонементом
+
+	// Реквизиты студии в квитанциях
+	StudioName    string `json:"studio_name" gorm:"type:varchar(200);not null;default:''"`
+	StudioAddress string `json:"studio_address" gorm:"type:text;not null;default:''"`
+	StudioPhone   string `json:"studio_phone" gorm:"type:varchar(30);not null;default:''"`
+	StudioTaxID   string `json:"studio_tax_id" gorm:"type:varchar(20);not null;default:''"` // РНОКПП или ЄДРПОУ
+
+	CreatedAt time.Time `json:"-"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type StudioSettingsInput struct {
+	CancelWindowHours  *int  `json:"cancel_window_hours" binding:"omitempty,min=0,max=168"`
+	VisitsByAttendance *bool `json:"visits_by_attendance"`
+	NoShowBurnsVisit   *bool `json:"no_show_burns_visit"`
+	NoShowLimit        *int  `json:"no_show_limit" binding:"omitempty,min=0,max=100"`
+	NoShowPeriodDays   *int  `json:"no_show_period_days" binding:"omitempty,min=1,max=365"`
+	NoShowBlockDays    *int  `json:"no_show_block_days" binding:"omitempty,min=0,max=365"`
+	LateCancelAsNoShow *bool `json:"late_cancel_as_no_show"`
+	HoldTTLMinutes     *int  `json:"hold_ttl_minutes" binding:"omitempty,min=1,max=60"`
+
+	WorkingHours *WorkingHours `json:"working_hours"`
+
+	SiblingDiscountPercent   *int `json:"sibling_discount_percent" binding:"omitempty,min=0,max=100"`
+	EarlyBirdDays            *int `json:"early_bird_days" binding:"omitempty,min=0,max=365"`
+	EarlyBirdDiscountPercent *int `json:"early_bird_discount_percent" binding:"omitempty,min=0,max=100"`
+	MemberDiscountPercent    *int `json:"member_discount_percent" binding:"omitempty,min=0,max=100"`
+
+	StudioName    *string `json:"studio_name" binding:"omitempty,max=200"`
+	StudioAddress *string `json:"studio_address" binding:"omitempty,max=500"`
+	StudioPhone   *string `json:"studio_phone" binding:"omitempty,max=30"`
+	StudioTaxID   *string `json:"studio_tax_id" binding:"omitempty,max=20"`
+}
+
+// Значения по умолчанию, если строка настроек ещё не создана миграцией
+func DefaultStudioSettings() StudioSettings {
+	return StudioSettings{
+		ID:                1,
+		CancelWindowHours: 12,
+		NoShowBurnsVisit:  true,
+		NoShowLimit:       3,
+		NoShowPeriodDays:  30,
+		NoShowBlockDays:   7,
+		HoldTTLMinutes:    10,
+		WorkingHours:      DefaultWorkingHours(),
+	}
+}
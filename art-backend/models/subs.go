@@ -22,6 +22,8 @@ type Subscription struct {
 	VisitsUsed  int `json:"visits_used" gorm:"not null;default:0"`
 
 	PricePaid uint `json:"price_paid" gorm:"not null"`
+
+	PromoCode string `json:"promo_code,omitempty" gorm:"-"` // Промокод при покупке, в цену уже учтён
 }
 
 type SubscriptionType struct {
@@ -2,13 +2,31 @@ package models
 
 import "time"
 
+// Шаблон еженедельного слота. Период действия и сезон позволяют держать рядом осеннее и летнее
+// расписание: в один день и время может быть несколько шаблонов, если их периоды не пересекаются
 type ScheduleTemplate struct {
-	ID         uint       `json:"id" gorm:"primaryKey;autoIncrement"`
-	ActivityID uint       `json:"activity_id" gorm:"not null;uniqueIndex:uniq_template"`
-	DayOfWeek  int        `json:"day_of_week" gorm:"not null;uniqueIndex:uniq_template"`
-	StartTime  string     `json:"start_time" gorm:"type:VARCHAR(5);not null;uniqueIndex:uniq_template"`
-	Capacity   int        `json:"capacity" gorm:"not null;default:10"`
-	CreatedAt  time.Time  `json:"created_at"`
-	UpdatedAt  time.Time  `json:"updated_at"`
-	DeletedAt  *time.Time `gorm:"index"`
+	ID         uint              `json:"id" gorm:"primaryKey;autoIncrement"`
+	ActivityID uint              `json:"activity_id" gorm:"not null"`
+	DayOfWeek  int               `json:"day_of_week" gorm:"not null"`
+	StartTime  string            `json:"start_time" gorm:"type:VARCHAR(5);not null"`
+	Capacity   int               `json:"capacity" gorm:"not null;default:10"`
+	AgeRange   `gorm:"embedded"` // Пустой — действуют ограничения занятия
+	ValidFrom  *time.Time        `json:"valid_from" gorm:"type:date"`                        // Пустой — действует с создания
+	ValidTo    *time.Time        `json:"valid_to" gorm:"type:date"`                          // Включительно, пустой — бессрочно
+	Season     string            `json:"season" gorm:"type:varchar(50);not null;default:''"` // Название сезона, например «Осінь 2026»
+	CreatedAt  time.Time         `json:"created_at"`
+	UpdatedAt  time.Time         `json:"updated_at"`
+	DeletedAt  *time.Time        `gorm:"index"`
+}
+
+// ValidOn проверяет, действует ли шаблон в день day. Время дня не учитывается
+func (t ScheduleTemplate) ValidOn(day time.Time) bool {
+	day = time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
+	if t.ValidFrom != nil && day.Before(*t.ValidFrom) {
+		return false
+	}
+	if t.ValidTo != nil && day.After(*t.ValidTo) {
+		return false
+	}
+	return true
 }
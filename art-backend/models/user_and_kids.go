@@ -8,15 +8,17 @@ import (
 
 type User struct {
 	gorm.Model
-	ID          uint      `json:"id" gorm:"primaryKey;autoIncrement"`
-	CreatedAt   time.Time `json:"created_at"`
-	Username    string    `json:"username" gorm:"type:varchar(50);unique;not null;size:50"`
-	Password    string    `json:"-" gorm:"type:varchar(255);not null"`
-	PhoneNumber string    `json:"phone_number" gorm:"type:varchar(15);unique;not null;size:15"`
-	Role        string    `json:"role" gorm:"type:varchar(20);not null;default:'client';size:20"`
-	Name        string    `json:"name" gorm:"type:varchar(100);size:100"`
-	Surname     string    `json:"surname" gorm:"type:varchar(100);size:100"`
-	UserKids    []UserKid `json:"user_kids" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE;"`
+	ID            uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	CreatedAt     time.Time `json:"created_at"`
+	Username      string    `json:"username" gorm:"type:varchar(50);unique;not null;size:50"`
+	Password      string    `json:"-" gorm:"type:varchar(255);not null"`
+	PhoneNumber   string    `json:"phone_number" gorm:"type:varchar(15);unique;not null;size:15"`
+	Role          string    `json:"role" gorm:"type:varchar(20);not null;default:'client';size:20"`
+	Name          string    `json:"name" gorm:"type:varchar(100);size:100"`
+	Surname       string    `json:"surname" gorm:"type:varchar(100);size:100"`
+	IsGuest       bool      `json:"is_guest" gorm:"not null;default:false"` // Облегчённый аккаунт, заведённый владельцем, войти в него нельзя до регистрации
+	CalendarToken *string   `json:"-" gorm:"type:varchar(64);unique"`       // Ключ ссылки на .ics-ленту записей клиента
+	UserKids      []UserKid `json:"user_kids" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE;"`
 }
 
 type UserKid struct {
@@ -0,0 +1,87 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	WaitlistStatusWaiting   = "waiting"
+	WaitlistStatusPromoted  = "promoted"
+	WaitlistStatusCancelled = "cancelled"
+
+	WaitlistReasonKidBooked = "kid_already_booked" // Ребёнок из заявки уже записан на слот другой записью
+)
+
+type WaitlistEntry struct {
+	gorm.Model
+	SlotID       uint       `json:"slot_id" gorm:"not null;index"`
+	ActivityID   uint       `json:"activity_id" gorm:"not null"`
+	UserID       uint       `json:"user_id" gorm:"not null;index"`
+	PhoneNumber  string     `json:"phone_number" gorm:"type:varchar(15);not null"`
+	ParentName   string     `json:"parent_name" gorm:"type:text"`
+	NumberOfKids uint       `json:"number_of_kids" gorm:"not null"`
+	Kids         KidList    `json:"kids" gorm:"type:jsonb;not null"`
+	Status       string     `json:"status" gorm:"type:varchar(20);not null;default:'waiting'"`
+	StatusReason string     `json:"status_reason" gorm:"type:varchar(50);not null;default:''"`
+	RecordID     *uint      `json:"record_id"`
+	PromotedAt   *time.Time `json:"promoted_at"`
+}
+
+type WaitlistRequest struct {
+	NumberOfKids uint  `json:"number_of_kids" binding:"required,gte=1"`
+	Kids         []Kid `json:"kids" binding:"required,dive"`
+}
+
+type WaitlistEntryResponse struct {
+	ID           uint       `json:"id"`
+	SlotID       uint       `json:"slot_id"`
+	ActivityID   uint       `json:"activity_id"`
+	NumberOfKids uint       `json:"number_of_kids"`
+	Kids         KidList    `json:"kids"`
+	Status       string     `json:"status"`
+	StatusReason string     `json:"status_reason,omitempty"`
+	Position     int        `json:"position,omitempty"` // Только для ожидающих, начиная с 1
+	RecordID     *uint      `json:"record_id,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	PromotedAt   *time.Time `json:"promoted_at,omitempty"`
+}
+
+func ToWaitlistEntryResponse(entry WaitlistEntry, position int) WaitlistEntryResponse {
+	return WaitlistEntryResponse{
+		ID:           entry.ID,
+		SlotID:       entry.SlotID,
+		ActivityID:   entry.ActivityID,
+		NumberOfKids: entry.NumberOfKids,
+		Kids:         entry.Kids,
+		Status:       entry.Status,
+		StatusReason: entry.StatusReason,
+		Position:     position,
+		RecordID:     entry.RecordID,
+		CreatedAt:    entry.CreatedAt,
+		PromotedAt:   entry.PromotedAt,
+	}
+}
+
+type KidList []Kid
+
+// Реализация driver.Valuer (для записи)
+func (k KidList) Value() (driver.Value, error) {
+	if k == nil {
+		return json.Marshal([]Kid{})
+	}
+	return json.Marshal([]Kid(k))
+}
+
+// Реализация sql.Scanner (для чтения)
+func (k *KidList) Scan(value interface{}) error {
+	bytes, ok := value.([]byte)
+	if !ok {
+		return fmt.Errorf("failed to scan KidList: %v", value)
+	}
+	return json.Unmarshal(bytes, k)
+}
@@ -0,0 +1,104 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Часы работы в один день недели, HH:MM. Close может быть 24:00 — до конца дня
+type DayHours struct {
+	Open  string `json:"open"`
+	Close string `json:"close"`
+}
+
+// WorkingHours — рабочие дни и часы по дням недели: 1 — понедельник, 7 — воскресенье, как DayOfWeek шаблона.
+// Дня нет в карте — выходной
+type WorkingHours map[int]DayHours
+
+// По умолчанию студия работает по будням без ограничения часов, как до настройки рабочих дней
+func DefaultWorkingHours() WorkingHours {
+	hours := WorkingHours{}
+	for day := 1; day <= 5; day++ {
+		hours[day] = DayHours{Open: "00:00", Close: "24:00"}
+	}
+	return hours
+}
+
+// Validate проверяет дни 1–7 и что каждый рабочий день открывается раньше, чем закрывается
+func (h WorkingHours) Validate() error {
+	for day, hours := range h {
+		if day < 1 || day > 7 {
+			return fmt.Errorf("day %d must be 1-7 (Mon-Sun)", day)
+		}
+		open, err := minuteOfDay(hours.Open)
+		if err != nil {
+			return fmt.Errorf("day %d: invalid open: %w", day, err)
+		}
+		closeAt, err := minuteOfDay(hours.Close)
+		if err != nil {
+			return fmt.Errorf("day %d: invalid close: %w", day, err)
+		}
+		if open >= closeAt {
+			return fmt.Errorf("day %d: open must be before close", day)
+		}
+	}
+	return nil
+}
+
+func (h WorkingHours) IsWorkingDay(day int) bool {
+	_, ok := h[day]
+	return ok
+}
+
+// Allows проверяет, что занятие длительностью duration минут с началом start (HH:MM) целиком
+// укладывается в рабочие часы дня
+func (h WorkingHours) Allows(day int, start string, duration uint) bool {
+	hours, ok := h[day]
+	if !ok {
+		return false
+	}
+	begin, err := minuteOfDay(start)
+	if err != nil {
+		return false
+	}
+	open, errOpen := minuteOfDay(hours.Open)
+	closeAt, errClose := minuteOfDay(hours.Close)
+	if errOpen != nil || errClose != nil {
+		return false
+	}
+	return begin >= open && begin+int(duration) <= closeAt
+}
+
+// minuteOfDay переводит HH:MM в минуты от полуночи, 24:00 — конец дня
+func minuteOfDay(s string) (int, error) {
+	hh, mm, ok := strings.Cut(s, ":")
+	if !ok || len(hh) < 1 || len(hh) > 2 || len(mm) != 2 {
+		return 0, fmt.Errorf("%q must be HH:MM", s)
+	}
+	hour, errH := strconv.Atoi(hh)
+	minute, errM := strconv.Atoi(mm)
+	if errH != nil || errM != nil || hour < 0 || minute < 0 || minute > 59 || hour > 24 || (hour == 24 && minute != 0) {
+		return 0, fmt.Errorf("%q must be HH:MM", s)
+	}
+	return hour*60 + minute, nil
+}
+
+// Реализация driver.Valuer (для записи)
+func (h WorkingHours) Value() (driver.Value, error) {
+	if h == nil {
+		return json.Marshal(map[int]DayHours{})
+	}
+	return json.Marshal(map[int]DayHours(h))
+}
+
+// Реализация sql.Scanner (для чтения)
+func (h *WorkingHours) Scan(value interface{}) error {
+	bytes, ok := value.([]byte)
+	if !ok {
+		return fmt.Errorf("failed to scan WorkingHours: %v", value)
+	}
+	return json.Unmarshal(bytes, h)
+}
@@ -0,0 +1,98 @@
+package pricing
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Quote — всё, что известно о записи на момент расчёта цены
+type Quote struct {
+	ActivityPrice uint      // Цена занятия за одного ребёнка
+	SlotPrice     *uint     // Цена, переопределённая для конкретного слота
+	Kids          uint      // Сколько детей в записи
+	BookedAt      time.Time // Когда клиент оформляет запись
+	SlotStart     time.Time // Начало занятия
+	IsMember      bool      // У клиента есть действующий абонемент
+}
+
+const PromoRuleName = "promo"
+
+// Adjustment — вклад одного правила в цену, отрицательный для скидок
+type Adjustment struct {
+	Rule   string `json:"rule"`
+	Label  string `json:"label"`
+	Amount int    `json:"amount"`
+}
+
+// Breakdown — расшифровка цены, сохраняется в записи как есть
+type Breakdown struct {
+	Base        uint         `json:"base"`
+	Adjustments []Adjustment `json:"adjustments"`
+	Total       uint         `json:"total"`
+}
+
+// Rule — одно правило ценообразования. subtotal — сумма после предыдущих правил.
+// Правило возвращает false, если к записи не применяется
+type Rule interface {
+	Apply(q Quote, subtotal uint) (Adjustment, bool)
+}
+
+type Engine struct {
+	rules []Rule
+}
+
+// NewEngine собирает движок из правил, которые применяются в переданном порядке
+func NewEngine(rules ...Rule) *Engine {
+	return &Engine{rules: rules}
+}
+
+func (e *Engine) Calculate(q Quote) Breakdown {
+	base := q.ActivityPrice * q.Kids
+	breakdown := Breakdown{Base: base, Adjustments: []Adjustment{}}
+
+	subtotal := int(base)
+	for _, rule := range e.rules {
+		adj, ok := rule.Apply(q, uint(subtotal))
+		if !ok || adj.Amount == 0 {
+			continue
+		}
+		if subtotal+adj.Amount < 0 { // Скидки не уводят цену ниже нуля
+			adj.Amount = -subtotal
+		}
+		subtotal += adj.Amount
+		breakdown.Adjustments = append(breakdown.Adjustments, adj)
+	}
+
+	breakdown.Total = uint(subtotal)
+	return breakdown
+}
+
+// Discount возвращает, на сколько уменьшило цену правило с данным именем
+func (b Breakdown) Discount(rule string) uint {
+	var discount int
+	for _, adj := range b.Adjustments {
+		if adj.Rule == rule {
+			discount -= adj.Amount
+		}
+	}
+	return uint(max(discount, 0))
+}
+
+// Реализация driver.Valuer (для записи)
+func (b Breakdown) Value() (driver.Value, error) {
+	return json.Marshal(b)
+}
+
+// Реализация sql.Scanner (для чтения)
+func (b *Breakdown) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return fmt.Errorf("failed to scan price breakdown: %v", value)
+	}
+	return json.Unmarshal(bytes, b)
+}
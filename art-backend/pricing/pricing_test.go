@@ -0,0 +1,223 @@
+package pricing
+
+import (
+	"testing"
+	"time"
+)
+
+func uintPtr(v uint) *uint {
+	return &v
+}
+
+func TestEngineCalculate(t *testing.T) {
+	slotStart := time.Date(2026, 3, 20, 10, 0, 0, 0, time.UTC)
+	earlyBird := EarlyBirdRule{Days: 7, Percent: 10}
+
+	tests := []struct {
+		name      string
+		rules     []Rule
+		quote     Quote
+		wantBase  uint
+		wantAdj   []Adjustment // Сравниваются только Rule и Amount
+		wantTotal uint
+	}{
+		{
+			name:      "no rules",
+			quote:     Quote{ActivityPrice: 300, Kids: 1},
+			wantBase:  300,
+			wantTotal: 300,
+		},
+		{
+			name:      "slot price replaces activity price per kid",
+			rules:     []Rule{SlotPriceRule{}},
+			quote:     Quote{ActivityPrice: 300, SlotPrice: uintPtr(250), Kids: 2},
+			wantBase:  600,
+			wantAdj:   []Adjustment{{Rule: "slot_price", Amount: -100}},
+			wantTotal: 500,
+		},
+		{
+			name:      "slot price equal to activity price is skipped",
+			rules:     []Rule{SlotPriceRule{}},
+			quote:     Quote{ActivityPrice: 300, SlotPrice: uintPtr(300), Kids: 1},
+			wantBase:  300,
+			wantTotal: 300,
+		},
+		{
+			name:      "sibling discount on every kid after the first",
+			rules:     []Rule{SiblingRule{Percent: 10}},
+			quote:     Quote{ActivityPrice: 300, Kids: 3},
+			wantBase:  900,
+			wantAdj:   []Adjustment{{Rule: "sibling", Amount: -60}},
+			wantTotal: 840,
+		},
+		{
+			name:      "sibling discount rounds down",
+			rules:     []Rule{SiblingRule{Percent: 15}},
+			quote:     Quote{ActivityPrice: 333, Kids: 2},
+			wantBase:  666,
+			wantAdj:   []Adjustment{{Rule: "sibling", Amount: -49}},
+			wantTotal: 617,
+		},
+		{
+			name:      "sibling discount needs two kids",
+			rules:     []Rule{SiblingRule{Percent: 10}},
+			quote:     Quote{ActivityPrice: 300, Kids: 1},
+			wantBase:  300,
+			wantTotal: 300,
+		},
+		{
+			name:      "early bird applies when booked in advance",
+			rules:     []Rule{earlyBird},
+			quote:     Quote{ActivityPrice: 500, Kids: 1, BookedAt: slotStart.AddDate(0, 0, -10), SlotStart: slotStart},
+			wantBase:  500,
+			wantAdj:   []Adjustment{{Rule: "early_bird", Amount: -50}},
+			wantTotal: 450,
+		},
+		{
+			name:      "early bird applies exactly at the threshold",
+			rules:     []Rule{earlyBird},
+			quote:     Quote{ActivityPrice: 500, Kids: 1, BookedAt: slotStart.AddDate(0, 0, -7), SlotStart: slotStart},
+			wantBase:  500,
+			wantAdj:   []Adjustment{{Rule: "early_bird", Amount: -50}},
+			wantTotal: 450,
+		},
+		{
+			name:      "early bird skipped for late booking",
+			rules:     []Rule{earlyBird},
+			quote:     Quote{ActivityPrice: 500, Kids: 1, BookedAt: slotStart.AddDate(0, 0, -3), SlotStart: slotStart},
+			wantBase:  500,
+			wantTotal: 500,
+		},
+		{
+			name:      "member discount",
+			rules:     []Rule{MemberRule{Percent: 5}},
+			quote:     Quote{ActivityPrice: 500, Kids: 1, IsMember: true},
+			wantBase:  500,
+			wantAdj:   []Adjustment{{Rule: "member", Amount: -25}},
+			wantTotal: 475,
+		},
+		{
+			name:      "member discount skipped without subscription",
+			rules:     []Rule{MemberRule{Percent: 5}},
+			quote:     Quote{ActivityPrice: 500, Kids: 1},
+			wantBase:  500,
+			wantTotal: 500,
+		},
+		{
+			name: "every rule applies to the subtotal of the previous ones",
+			rules: []Rule{
+				SlotPriceRule{},
+				SiblingRule{Percent: 50},
+				earlyBird,
+				MemberRule{Percent: 20},
+				PromoRule{Code: "SPRING", Amount: 100},
+			},
+			quote: Quote{
+				ActivityPrice: 400,
+				SlotPrice:     uintPtr(300),
+				Kids:          2,
+				BookedAt:      slotStart.AddDate(0, 0, -14),
+				SlotStart:     slotStart,
+				IsMember:      true,
+			},
+			wantBase: 800,
+			wantAdj: []Adjustment{
+				{Rule: "slot_price", Amount: -200},
+				{Rule: "sibling", Amount: -150},
+				{Rule: "early_bird", Amount: -45},
+				{Rule: "member", Amount: -81},
+				{Rule: PromoRuleName, Amount: -100},
+			},
+			wantTotal: 224,
+		},
+		{
+			name:      "member before fixed promo",
+			rules:     []Rule{MemberRule{Percent: 50}, PromoRule{Code: "A", Amount: 100}},
+			quote:     Quote{ActivityPrice: 999, Kids: 1, IsMember: true},
+			wantBase:  999,
+			wantAdj:   []Adjustment{{Rule: "member", Amount: -499}, {Rule: PromoRuleName, Amount: -100}},
+			wantTotal: 400,
+		},
+		{
+			name:      "fixed promo before member",
+			rules:     []Rule{PromoRule{Code: "A", Amount: 100}, MemberRule{Percent: 50}},
+			quote:     Quote{ActivityPrice: 999, Kids: 1, IsMember: true},
+			wantBase:  999,
+			wantAdj:   []Adjustment{{Rule: PromoRuleName, Amount: -100}, {Rule: "member", Amount: -449}},
+			wantTotal: 450,
+		},
+		{
+			name:      "percent promo",
+			rules:     []Rule{PromoRule{Code: "A", Percent: 15}},
+			quote:     Quote{ActivityPrice: 250, Kids: 1},
+			wantBase:  250,
+			wantAdj:   []Adjustment{{Rule: PromoRuleName, Amount: -37}},
+			wantTotal: 213,
+		},
+		{
+			name:      "promo without discount is skipped",
+			rules:     []Rule{PromoRule{Code: "A"}},
+			quote:     Quote{ActivityPrice: 250, Kids: 1},
+			wantBase:  250,
+			wantTotal: 250,
+		},
+		{
+			name:      "discount never goes below zero",
+			rules:     []Rule{PromoRule{Code: "A", Amount: 150}},
+			quote:     Quote{ActivityPrice: 100, Kids: 1},
+			wantBase:  100,
+			wantAdj:   []Adjustment{{Rule: PromoRuleName, Amount: -100}},
+			wantTotal: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewEngine(tt.rules...).Calculate(tt.quote)
+
+			if got.Base != tt.wantBase {
+				t.Errorf("base = %d, want %d", got.Base, tt.wantBase)
+			}
+			if got.Total != tt.wantTotal {
+				t.Errorf("total = %d, want %d", got.Total, tt.wantTotal)
+			}
+			if len(got.Adjustments) != len(tt.wantAdj) {
+				t.Fatalf("adjustments = %+v, want %+v", got.Adjustments, tt.wantAdj)
+			}
+			sum := int(got.Base)
+			for i, adj := range got.Adjustments {
+				if adj.Rule != tt.wantAdj[i].Rule || adj.Amount != tt.wantAdj[i].Amount {
+					t.Errorf("adjustment %d = %s %d, want %s %d", i, adj.Rule, adj.Amount, tt.wantAdj[i].Rule, tt.wantAdj[i].Amount)
+				}
+				if adj.Label == "" {
+					t.Errorf("adjustment %d (%s) has no label", i, adj.Rule)
+				}
+				sum += adj.Amount
+			}
+			if sum != int(got.Total) {
+				t.Errorf("base plus adjustments = %d, total = %d", sum, got.Total)
+			}
+		})
+	}
+}
+
+func TestBreakdownDiscount(t *testing.T) {
+	breakdown := NewEngine(
+		SiblingRule{Percent: 10},
+		PromoRule{Code: "A", Amount: 1000},
+	).Calculate(Quote{ActivityPrice: 200, Kids: 2})
+
+	tests := []struct {
+		rule string
+		want uint
+	}{
+		{rule: "sibling", want: 20},
+		{rule: PromoRuleName, want: 380},
+		{rule: "member", want: 0},
+	}
+	for _, tt := range tests {
+		if got := breakdown.Discount(tt.rule); got != tt.want {
+			t.Errorf("Discount(%q) = %d, want %d", tt.rule, got, tt.want)
+		}
+	}
+}
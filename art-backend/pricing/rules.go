@@ -0,0 +1,97 @@
+package pricing
+
+import (
+	"fmt"
+	"time"
+)
+
+func percentOf(amount uint, percent int) int {
+	return int(amount) * percent / 100
+}
+
+// SlotPriceRule подменяет цену занятия ценой, заданной для слота
+type SlotPriceRule struct{}
+
+func (SlotPriceRule) Apply(q Quote, subtotal uint) (Adjustment, bool) {
+	if q.SlotPrice == nil || *q.SlotPrice == q.ActivityPrice {
+		return Adjustment{}, false
+	}
+	return Adjustment{
+		Rule:   "slot_price",
+		Label:  fmt.Sprintf("Ціна заняття %d замість %d", *q.SlotPrice, q.ActivityPrice),
+		Amount: (int(*q.SlotPrice) - int(q.ActivityPrice)) * int(q.Kids),
+	}, true
+}
+
+// SiblingRule даёт скидку на каждого ребёнка после первого
+type SiblingRule struct {
+	Percent int
+}
+
+func (r SiblingRule) Apply(q Quote, subtotal uint) (Adjustment, bool) {
+	if r.Percent <= 0 || q.Kids < 2 {
+		return Adjustment{}, false
+	}
+	extraKids := subtotal / q.Kids * (q.Kids - 1)
+	return Adjustment{
+		Rule:   "sibling",
+		Label:  fmt.Sprintf("Знижка %d%% на другу та наступних дітей", r.Percent),
+		Amount: -percentOf(extraKids, r.Percent),
+	}, true
+}
+
+// EarlyBirdRule даёт скидку при записи не позже чем за Days дней до занятия
+type EarlyBirdRule struct {
+	Days    int
+	Percent int
+}
+
+func (r EarlyBirdRule) Apply(q Quote, subtotal uint) (Adjustment, bool) {
+	if r.Percent <= 0 || r.Days <= 0 {
+		return Adjustment{}, false
+	}
+	if q.SlotStart.Sub(q.BookedAt) < time.Duration(r.Days)*24*time.Hour {
+		return Adjustment{}, false
+	}
+	return Adjustment{
+		Rule:   "early_bird",
+		Label:  fmt.Sprintf("Знижка %d%% за запис за %d днів", r.Percent, r.Days),
+		Amount: -percentOf(subtotal, r.Percent),
+	}, true
+}
+
+// MemberRule даёт скидку клиентам с действующим абонементом
+type MemberRule struct {
+	Percent int
+}
+
+func (r MemberRule) Apply(q Quote, subtotal uint) (Adjustment, bool) {
+	if r.Percent <= 0 || !q.IsMember {
+		return Adjustment{}, false
+	}
+	return Adjustment{
+		Rule:   "member",
+		Label:  fmt.Sprintf("Знижка %d%% для власників абонемента", r.Percent),
+		Amount: -percentOf(subtotal, r.Percent),
+	}, true
+}
+
+// PromoRule — скидка по промокоду, применяется последней
+type PromoRule struct {
+	Code    string
+	Percent int  // Скидка в процентах
+	Amount  uint // Или фиксированная сумма
+}
+
+func (r PromoRule) Apply(q Quote, subtotal uint) (Adjustment, bool) {
+	adj := Adjustment{Rule: PromoRuleName, Label: "Промокод " + r.Code}
+	switch {
+	case r.Percent > 0:
+		adj.Amount = -percentOf(subtotal, r.Percent)
+	case r.Amount > 0:
+		adj.Amount = -int(r.Amount)
+	default:
+		return Adjustment{}, false
+	}
+	return adj, true
+}
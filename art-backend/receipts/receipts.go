@@ -0,0 +1,129 @@
+package receipts
+
+import (
+	_ "embed"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+)
+
+//go:embed fonts/DejaVuSansCondensed.ttf
+var fontRegular []byte
+
+//go:embed fonts/DejaVuSansCondensed-Bold.ttf
+var fontBold []byte
+
+const fontFamily = "DejaVu"
+
+// Реквизиты студии в шапке документа
+type Studio struct {
+	Name    string
+	Address string
+	Phone   string
+	TaxID   string
+}
+
+// Line — строка документа. Amount отрицательный у скидок
+type Line struct {
+	Title   string
+	Details []string
+	Amount  int
+}
+
+// Document — квитанция об оплате или подтверждение записи. У подтверждения нет номера
+type Document struct {
+	Title    string
+	Number   string
+	IssuedAt time.Time
+	Studio   Studio
+	Customer string
+	Phone    string
+	Lines    []Line
+	Total    uint
+	Notes    []string
+}
+
+// Render рисует документ на одной странице A4 и пишет PDF в w
+func Render(w io.Writer, doc Document) error {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.AddUTF8FontFromBytes(fontFamily, "", fontRegular)
+	pdf.AddUTF8FontFromBytes(fontFamily, "B", fontBold)
+	pdf.SetTitle(doc.Title, true)
+	pdf.SetCreator(doc.Studio.Name, true)
+	pdf.SetMargins(20, 20, 20)
+	pdf.SetAutoPageBreak(true, 20)
+	pdf.AddPage()
+
+	pageWidth, _ := pdf.GetPageSize()
+	contentWidth := pageWidth - 40
+
+	// Шапка со студией
+	pdf.SetFont(fontFamily, "B", 14)
+	pdf.CellFormat(contentWidth, 8, doc.Studio.Name, "", 1, "L", false, 0, "")
+	pdf.SetFont(fontFamily, "", 9)
+	for _, line := range []string{doc.Studio.Address, doc.Studio.Phone, taxLine(doc.Studio.TaxID)} {
+		if line != "" {
+			pdf.CellFormat(contentWidth, 5, line, "", 1, "L", false, 0, "")
+		}
+	}
+	pdf.Ln(6)
+
+	title := doc.Title
+	if doc.Number != "" {
+		title += " № " + doc.Number
+	}
+	pdf.SetFont(fontFamily, "B", 16)
+	pdf.CellFormat(contentWidth, 10, title, "", 1, "C", false, 0, "")
+	pdf.SetFont(fontFamily, "", 10)
+	pdf.CellFormat(contentWidth, 6, "від "+doc.IssuedAt.Format("02.01.2006 15:04"), "", 1, "C", false, 0, "")
+	pdf.Ln(6)
+
+	pdf.CellFormat(contentWidth, 6, "Клієнт: "+doc.Customer, "", 1, "L", false, 0, "")
+	if doc.Phone != "" {
+		pdf.CellFormat(contentWidth, 6, "Телефон: "+doc.Phone, "", 1, "L", false, 0, "")
+	}
+	pdf.Ln(4)
+
+	// Таблица позиций
+	amountWidth := 35.0
+	pdf.SetFont(fontFamily, "B", 10)
+	pdf.CellFormat(contentWidth-amountWidth, 8, "Послуга", "B", 0, "L", false, 0, "")
+	pdf.CellFormat(amountWidth, 8, "Сума, грн", "B", 1, "R", false, 0, "")
+
+	for _, line := range doc.Lines {
+		pdf.SetFont(fontFamily, "", 10)
+		pdf.CellFormat(contentWidth-amountWidth, 7, line.Title, "", 0, "L", false, 0, "")
+		pdf.CellFormat(amountWidth, 7, fmt.Sprintf("%d", line.Amount), "", 1, "R", false, 0, "")
+		pdf.SetFont(fontFamily, "", 9)
+		pdf.SetTextColor(90, 90, 90)
+		for _, detail := range line.Details {
+			pdf.MultiCell(contentWidth-amountWidth, 5, "   "+detail, "", "L", false)
+		}
+		pdf.SetTextColor(0, 0, 0)
+	}
+
+	pdf.SetFont(fontFamily, "B", 11)
+	pdf.CellFormat(contentWidth-amountWidth, 9, "Разом", "T", 0, "L", false, 0, "")
+	pdf.CellFormat(amountWidth, 9, fmt.Sprintf("%d", doc.Total), "T", 1, "R", false, 0, "")
+	pdf.Ln(6)
+
+	if len(doc.Notes) > 0 {
+		pdf.SetFont(fontFamily, "", 9)
+		pdf.MultiCell(contentWidth, 5, strings.Join(doc.Notes, "\n"), "", "L", false)
+	}
+
+	if err := pdf.Error(); err != nil {
+		return fmt.Errorf("failed to render receipt: %w", err)
+	}
+	return pdf.Output(w)
+}
+
+func taxLine(taxID string) string {
+	if taxID == "" {
+		return ""
+	}
+	return "РНОКПП/ЄДРПОУ: " + taxID
+}
@@ -0,0 +1,184 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func bits(values ...int) uint64 {
+	var b uint64
+	for _, v := range values {
+		b |= 1 << v
+	}
+	return b
+}
+
+func span(lo, hi int) uint64 {
+	var b uint64
+	for v := lo; v <= hi; v++ {
+		b |= 1 << v
+	}
+	return b
+}
+
+func TestParseCronField(t *testing.T) {
+	tests := []struct {
+		field    string
+		min, max int
+		want     uint64
+	}{
+		{field: "*", min: 0, max: 59, want: span(0, 59)},
+		{field: "0", min: 0, max: 59, want: bits(0)},
+		{field: "59", min: 0, max: 59, want: bits(59)},
+		{field: "1-5", min: 0, max: 23, want: span(1, 5)},
+		{field: "*/15", min: 0, max: 59, want: bits(0, 15, 30, 45)},
+		{field: "5/15", min: 0, max: 59, want: bits(5, 20, 35, 50)},
+		{field: "10-20/5", min: 0, max: 59, want: bits(10, 15, 20)},
+		{field: "10-21/5", min: 0, max: 59, want: bits(10, 15, 20)},
+		{field: "1,3,5", min: 0, max: 6, want: bits(1, 3, 5)},
+		{field: "1-3,10,20-30/10", min: 0, max: 59, want: bits(1, 2, 3, 10, 20, 30)},
+		{field: "3,3", min: 0, max: 6, want: bits(3)},
+		{field: "*/2", min: 1, max: 12, want: bits(1, 3, 5, 7, 9, 11)},
+	}
+
+	for _, tt := range tests {
+		got, err := parseCronField(tt.field, tt.min, tt.max)
+		if err != nil {
+			t.Errorf("parseCronField(%q) error: %v", tt.field, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseCronField(%q) = %b, want %b", tt.field, got, tt.want)
+		}
+	}
+}
+
+func TestParseCron(t *testing.T) {
+	tests := []struct {
+		expr string
+		want Schedule
+	}{
+		{
+			expr: "0 * * * *",
+			want: Schedule{minute: bits(0), hour: span(0, 23), dom: span(1, 31), month: span(1, 12), dow: span(0, 7), domAny: true, dowAny: true},
+		},
+		{
+			expr: "  30 2 1,15 */3 1-5 ",
+			want: Schedule{minute: bits(30), hour: bits(2), dom: bits(1, 15), month: bits(1, 4, 7, 10), dow: span(1, 5)},
+		},
+		{
+			expr: "0 0 * * 0",
+			want: Schedule{minute: bits(0), hour: bits(0), dom: span(1, 31), month: span(1, 12), dow: bits(0), domAny: true},
+		},
+		{
+			expr: "0 0 * * 7",
+			want: Schedule{minute: bits(0), hour: bits(0), dom: span(1, 31), month: span(1, 12), dow: bits(0, 7), domAny: true},
+		},
+		{
+			expr: "0 0 * * 5-7",
+			want: Schedule{minute: bits(0), hour: bits(0), dom: span(1, 31), month: span(1, 12), dow: bits(0, 5, 6, 7), domAny: true},
+		},
+		{
+			expr: "@daily",
+			want: Schedule{minute: bits(0), hour: bits(0), dom: span(1, 31), month: span(1, 12), dow: span(0, 7), domAny: true, dowAny: true},
+		},
+		{
+			expr: "@weekly",
+			want: Schedule{minute: bits(0), hour: bits(0), dom: span(1, 31), month: span(1, 12), dow: bits(0), domAny: true},
+		},
+		{
+			expr: "@monthly",
+			want: Schedule{minute: bits(0), hour: bits(0), dom: bits(1), month: span(1, 12), dow: span(0, 7), dowAny: true},
+		},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseCron(tt.expr)
+		if err != nil {
+			t.Errorf("ParseCron(%q) error: %v", tt.expr, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseCron(%q) = %+v, want %+v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestParseCronInvalid(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"@yearly",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * 32 * *",
+		"* * * 0 *",
+		"* * * 13 *",
+		"* * * * 8",
+		"-1 * * * *",
+		"a * * * *",
+		"5-1 * * * *",
+		"1-x * * * *",
+		"*/0 * * * *",
+		"*/x * * * *",
+		"*/-5 * * * *",
+		"1,,2 * * * *",
+		"1, * * * *",
+	} {
+		if _, err := ParseCron(expr); err == nil {
+			t.Errorf("ParseCron(%q) accepted invalid expression", expr)
+		}
+	}
+}
+
+func TestScheduleNext(t *testing.T) {
+	at := func(year int, month time.Month, day, hour, minute int) time.Time {
+		return time.Date(year, month, day, hour, minute, 0, 0, time.UTC)
+	}
+	kyiv := time.FixedZone("EET", 2*60*60)
+
+	tests := []struct {
+		name string
+		expr string
+		from time.Time
+		want time.Time
+	}{
+		{name: "strictly after a matching minute", expr: "0 * * * *", from: at(2026, 3, 14, 10, 0), want: at(2026, 3, 14, 11, 0)},
+		{name: "seconds are dropped", expr: "* * * * *", from: at(2026, 3, 14, 10, 0).Add(59 * time.Second), want: at(2026, 3, 14, 10, 1)},
+		{name: "step", expr: "*/15 * * * *", from: at(2026, 3, 14, 10, 7), want: at(2026, 3, 14, 10, 15)},
+		{name: "step rolls over the hour", expr: "*/15 * * * *", from: at(2026, 3, 14, 10, 50), want: at(2026, 3, 14, 11, 0)},
+		{name: "next day", expr: "30 2 * * *", from: at(2026, 3, 14, 3, 0), want: at(2026, 3, 15, 2, 30)},
+		{name: "hour list", expr: "0 9,18 * * *", from: at(2026, 3, 14, 9, 0), want: at(2026, 3, 14, 18, 0)},
+		{name: "sunday as 0", expr: "0 0 * * 0", from: at(2026, 3, 14, 10, 0), want: at(2026, 3, 15, 0, 0)},
+		{name: "sunday as 7", expr: "0 0 * * 7", from: at(2026, 3, 14, 10, 0), want: at(2026, 3, 15, 0, 0)},
+		{name: "weekdays skip the weekend", expr: "0 9 * * 1-5", from: at(2026, 3, 13, 10, 0), want: at(2026, 3, 16, 9, 0)},
+		{name: "day of month or day of week", expr: "0 0 10 * 5", from: at(2026, 3, 7, 0, 0), want: at(2026, 3, 10, 0, 0)},
+		{name: "day of week or day of month", expr: "0 0 10 * 5", from: at(2026, 3, 10, 0, 0), want: at(2026, 3, 13, 0, 0)},
+		{name: "monthly across the year", expr: "@monthly", from: at(2026, 12, 15, 12, 0), want: at(2027, 1, 1, 0, 0)},
+		{name: "last minute of the year", expr: "59 23 31 12 *", from: at(2026, 12, 31, 23, 59), want: at(2027, 12, 31, 23, 59)},
+		{name: "month list", expr: "0 0 1 1,7 *", from: at(2026, 2, 1, 0, 0), want: at(2026, 7, 1, 0, 0)},
+		{name: "leap day", expr: "0 0 29 2 *", from: at(2026, 3, 1, 0, 0), want: at(2028, 2, 29, 0, 0)},
+		{name: "impossible date", expr: "0 0 31 2 *", from: at(2026, 1, 1, 0, 0), want: time.Time{}},
+		{name: "local time is converted to UTC", expr: "0 * * * *", from: time.Date(2026, 3, 14, 12, 30, 0, 0, kyiv), want: at(2026, 3, 14, 11, 0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := ParseCron(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseCron(%q) error: %v", tt.expr, err)
+			}
+			if got := s.Next(tt.from); !got.Equal(tt.want) {
+				t.Errorf("Next(%s) = %s, want %s", tt.from, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNeverNext(t *testing.T) {
+	if got := Never.Next(time.Date(2026, 3, 14, 10, 0, 0, 0, time.UTC)); !got.IsZero() {
+		t.Errorf("Never.Next = %s, want zero time", got)
+	}
+}
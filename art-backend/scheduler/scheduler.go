@@ -0,0 +1,224 @@
+package scheduler
+
+import (
+	"art/models"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+const (
+	TriggerSchedule = "schedule"
+	TriggerManual   = "manual"
+)
+
+var (
+	ErrUnknownJob = errors.New("unknown job")
+	ErrJobBusy    = errors.New("job is already running")
+)
+
+// Result — что сделал запуск, сохраняется в историю
+type Result struct {
+	SlotsCreated int
+	ErrorsRaised int // Сколько сообщений задача сохранила в studio_errors
+}
+
+type Job struct {
+	Name     string
+	Schedule Schedule
+	Timeout  time.Duration // Сколько держится блокировка; задача должна успеть за это время
+	Run      func(ctx context.Context) (Result, error)
+}
+
+// Scheduler запускает задачи по расписанию внутри процесса. Каждая реплика бэкенда держит свой
+// планировщик, а Redis решает, кто из них выполнит очередной запуск
+type Scheduler struct {
+	db       *gorm.DB
+	redis    *redis.Client
+	instance string
+	jobs     map[string]Job
+	ctx      context.Context // Контекст процесса из Start, ручные запуски тоже останавливаются с ним
+	wg       sync.WaitGroup
+}
+
+func New(db *gorm.DB, redisClient *redis.Client, jobs ...Job) *Scheduler {
+	instance, _ := os.Hostname()
+	s := &Scheduler{db: db, redis: redisClient, instance: instance, jobs: make(map[string]Job, len(jobs)), ctx: context.Background()}
+	for _, job := range jobs {
+		s.jobs[job.Name] = job
+	}
+	return s
+}
+
+// Start запускает по горутине на задачу и возвращается сразу. Задачи останавливаются с отменой ctx
+func (s *Scheduler) Start(ctx context.Context) {
+	s.ctx = ctx
+	for _, job := range s.jobs {
+		s.wg.Add(1)
+		go s.loop(ctx, job)
+	}
+}
+
+// Wait дожидается завершения выполняющихся задач после отмены контекста
+func (s *Scheduler) Wait() {
+	s.wg.Wait()
+}
+
+func (s *Scheduler) loop(ctx context.Context, job Job) {
+	defer s.wg.Done()
+
+	for {
+		next := job.Schedule.Next(time.Now())
+		if next.IsZero() {
+			log.Info().Str("job", job.Name).Msg("Job has no scheduled runs, manual trigger only")
+			return
+		}
+		log.Info().Str("job", job.Name).Time("next_run", next).Msg("Job scheduled")
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		// Все реплики просыпаются в одну минуту, запуск достаётся той, что первой занесёт его в Redis
+		claimed, err := s.redis.SetNX(ctx, fmt.Sprintf("scheduler:claim:%s:%d", job.Name, next.Unix()), s.instance, 24*time.Hour).Result()
+		if err != nil {
+			log.Error().Err(err).Str("job", job.Name).Msg("Failed to claim job run")
+			continue
+		}
+		if !claimed {
+			log.Debug().Str("job", job.Name).Msg("Job run claimed by another instance")
+			continue
+		}
+
+		if _, err := s.execute(ctx, job, TriggerSchedule); err != nil && !errors.Is(err, ErrJobBusy) {
+			log.Error().Err(err).Str("job", job.Name).Msg("Scheduled job failed")
+		}
+	}
+}
+
+// RunNow запускает задачу вне расписания в фоне. ErrJobBusy — задача уже выполняется на какой-то реплике;
+// блокировку может успеть занять и другой запуск между проверкой и стартом, тогда он просто будет пропущен
+func (s *Scheduler) RunNow(name string) error {
+	job, ok := s.jobs[name]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownJob, name)
+	}
+	busy, err := s.redis.Exists(s.ctx, "scheduler:lock:"+job.Name).Result()
+	if err != nil {
+		return fmt.Errorf("failed to check job lock: %w", err)
+	}
+	if busy > 0 {
+		return ErrJobBusy
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		if _, err := s.execute(s.ctx, job, TriggerManual); err != nil && !errors.Is(err, ErrJobBusy) {
+			log.Error().Err(err).Str("job", job.Name).Msg("Manual job run failed")
+		}
+	}()
+	return nil
+}
+
+// Jobs — имена задач и время ближайшего запуска
+func (s *Scheduler) Jobs() map[string]time.Time {
+	now := time.Now()
+	next := make(map[string]time.Time, len(s.jobs))
+	for name, job := range s.jobs {
+		next[name] = job.Schedule.Next(now)
+	}
+	return next
+}
+
+// execute держит блокировку задачи на время выполнения, чтобы запуск по расписанию
+// и ручной запуск на разных репликах не пересеклись, и пишет историю запуска
+func (s *Scheduler) execute(ctx context.Context, job Job, trigger string) (models.JobRun, error) {
+	lockKey := "scheduler:lock:" + job.Name
+	token, err := lockToken()
+	if err != nil {
+		return models.JobRun{}, err
+	}
+	acquired, err := s.redis.SetNX(ctx, lockKey, token, job.Timeout).Result()
+	if err != nil {
+		return models.JobRun{}, fmt.Errorf("failed to lock job: %w", err)
+	}
+	if !acquired {
+		log.Info().Str("job", job.Name).Msg("Job is already running, skipped")
+		return models.JobRun{}, ErrJobBusy
+	}
+	defer s.unlock(lockKey, token)
+
+	run := models.JobRun{
+		JobName:   job.Name,
+		Trigger:   trigger,
+		Instance:  s.instance,
+		Status:    models.JobRunRunning,
+		StartedAt: time.Now().UTC(),
+	}
+	if err := s.db.Create(&run).Error; err != nil {
+		return run, fmt.Errorf("failed to save job run: %w", err)
+	}
+	log.Info().Str("job", job.Name).Str("trigger", trigger).Uint("run_id", run.ID).Msg("Job started")
+
+	jobCtx, cancel := context.WithTimeout(ctx, job.Timeout)
+	result, runErr := job.Run(jobCtx)
+	cancel()
+
+	finished := time.Now().UTC()
+	run.FinishedAt = &finished
+	run.SlotsCreated = result.SlotsCreated
+	run.ErrorsRaised = result.ErrorsRaised
+	run.Status = models.JobRunSuccess
+	if runErr != nil {
+		run.Status = models.JobRunFailed
+		run.Error = runErr.Error()
+	}
+
+	if err := s.db.Save(&run).Error; err != nil {
+		log.Error().Err(err).Str("job", job.Name).Uint("run_id", run.ID).Msg("Failed to save job run result")
+	}
+
+	log.Info().
+		Str("job", job.Name).
+		Str("status", run.Status).
+		Int("slots_created", run.SlotsCreated).
+		Int("errors_raised", run.ErrorsRaised).
+		Dur("duration", finished.Sub(run.StartedAt)).
+		Msg("Job finished")
+	return run, runErr
+}
+
+// Снимаем только свою блокировку: если задача не уложилась в Timeout, ключ мог занять другой запуск
+var unlockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+func (s *Scheduler) unlock(key, token string) {
+	if err := unlockScript.Run(context.Background(), s.redis, []string{key}, token).Err(); err != nil {
+		log.Error().Err(err).Str("key", key).Msg("Failed to release job lock")
+	}
+}
+
+func lockToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate lock token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -2,20 +2,24 @@ package utils
 
 import (
 	"art/database"
+	"context"
 
 	"github.com/gin-gonic/gin"
 	"github.com/rs/zerolog/log"
 )
 
 func InvalidateCache(c *gin.Context, pattern ...string) {
+	InvalidateCacheCtx(c.Request.Context(), pattern...)
+}
+
+// InvalidateCacheCtx — то же для кода вне HTTP-запроса, например фоновых задач
+func InvalidateCacheCtx(ctx context.Context, pattern ...string) {
 	redisClient, err := database.GetRedis()
 	if err != nil || redisClient == nil {
 		log.Warn().Err(err).Msg("Redis not available, skipping cache invalidation")
 		return
 	}
 
-	ctx := c.Request.Context()
-
 	for _, pattern := range pattern {
 		cursor := uint64(0)
 		for {
@@ -58,3 +58,27 @@ func CombineDateAndTemplateTime(targetDate time.Time, tmplTime time.Time) time.T
 	year, month, day := targetDate.Date()
 	return time.Date(year, month, day, tmplTime.Hour(), tmplTime.Minute(), 0, 0, time.UTC)
 }
+
+// ParseDateRange разбирает границы периода в формате YYYY-MM-DD.
+// Пустая граница остаётся нулевой, to возвращается как начало следующего дня, чтобы сравнивать через "<"
+func ParseDateRange(from, to string) (time.Time, time.Time, error) {
+	var start, end time.Time
+	if from != "" {
+		t, err := time.ParseInLocation("2006-01-02", from, time.UTC)
+		if err != nil {
+			return start, end, fmt.Errorf("invalid from date: %s", from)
+		}
+		start = t
+	}
+	if to != "" {
+		t, err := time.ParseInLocation("2006-01-02", to, time.UTC)
+		if err != nil {
+			return start, end, fmt.Errorf("invalid to date: %s", to)
+		}
+		end = t.AddDate(0, 0, 1)
+	}
+	if !start.IsZero() && !end.IsZero() && !start.Before(end) {
+		return start, end, fmt.Errorf("from date must not be after to date")
+	}
+	return start, end, nil
+}
@@ -28,6 +28,8 @@ const AdminTemplatesPage = () => {
     3: 'Сeреда',
     4: 'Четвер',
     5: 'П\'ятниця',
+    6: 'Субота',
+    7: 'Неділя',
   };
 
 
@@ -17,6 +17,8 @@ const Register = ({ adminMode = false }) => {
     const [name, setName] = useState('');
     const [surname, setSurname] = useState('');
     const [role, setRole] = useState('client');
+    const [claimCode, setClaimCode] = useState('');
+    const [needsClaimCode, setNeedsClaimCode] = useState(false); // На телефон уже заведён гостевой акаунт
 
     useEffect(() => {
     setIsAdminMode(adminMode || (isAuthenticated && user?.role === 'owner'));
@@ -76,7 +78,7 @@ const Register = ({ adminMode = false }) => {
 
     try {
       const endpoint = isAdminMode ? '/admin/register' : '/register';
-      await api.post(endpoint, { username, password, phone_number, name, surname, role });
+      await api.post(endpoint, { username, password, phone_number, name, surname, role, claim_code: claimCode });
       
       if (isAdminMode) { // Очистка формы для следующего пользователя
         setUsername('');
@@ -91,6 +93,8 @@ const Register = ({ adminMode = false }) => {
         navigate('/login');
       }
     } catch (err) {
+      const reason = err.response?.data?.reason;
+      if (reason === 'guest_account_exists' || reason === 'invalid_claim_code') setNeedsClaimCode(true);
       setError(err.response?.data?.error || 'Помилка створення профілю');
     }
   };
@@ -140,6 +144,20 @@ const Register = ({ adminMode = false }) => {
                 <label className="register-form-label">Прізвище:</label>
                 <input type="text" value={surname} onChange={(e) => handleSurnameChange(e)} className="register-form-input" placeholder="Ваше прізвище" required />
             </div>
+            {!isAdminMode && needsClaimCode && (
+            <div className="register-form-group">
+                <label className="register-form-label">Код від студії:</label>
+                <input
+                type="text"
+                value={claimCode}
+                onChange={(e) => setClaimCode(e.target.value.replace(/\D/g, ''))}
+                className="register-form-input"
+                inputMode="numeric"
+                maxLength={6}
+                required
+                />
+            </div>
+            )}
             {isAdminMode && (
             <div className="register-form-group">
               <label htmlFor="role" className="register-form-label">Роль користувача</label>
@@ -3,7 +3,7 @@ import '@styles/TemplateModal.css';
 
 const AddEditTemplateModal = ({ show, onHide, onSave, template, activities }) => {
   const [formData, setFormData] = useState(
-    template || { activity_id: 0, day_of_week: 1, start_time: '10:00', capacity: 10 }
+    template || { activity_id: 0, day_of_week: 1, start_time: '10:00', capacity: 10, valid_from: '', valid_to: '', season: '' }
   );
 
   useEffect(() => {
@@ -15,9 +15,12 @@ const AddEditTemplateModal = ({ show, onHide, onSave, template, activities }) =>
         day_of_week: template.day_of_week || 1,
         start_time: hhmm,
         capacity: template.capacity || 10,
+        valid_from: template.valid_from ? template.valid_from.slice(0, 10) : '',
+        valid_to: template.valid_to ? template.valid_to.slice(0, 10) : '',
+        season: template.season || '',
       });
     } else {
-      setFormData({ activity_id: 0, day_of_week: 1, start_time: '10:00', capacity: 10 });
+      setFormData({ activity_id: 0, day_of_week: 1, start_time: '10:00', capacity: 10, valid_from: '', valid_to: '', season: '' });
     }
   }, [template]);
 
@@ -72,6 +75,8 @@ const AddEditTemplateModal = ({ show, onHide, onSave, template, activities }) =>
             <option value={3}>Середа</option>
             <option value={4}>Четвер</option>
             <option value={5}>П'ятниця</option>
+            <option value={6}>Субота</option>
+            <option value={7}>Неділя</option>
           </select>
 
         <label className="template-modal-label">Час початку (HH:MM):</label>
@@ -93,6 +98,34 @@ const AddEditTemplateModal = ({ show, onHide, onSave, template, activities }) =>
           className="template-form-input"
         />
 
+        <label className="template-modal-label">Сезон (необов'язково):</label>
+        <input
+          type="text"
+          name="season"
+          maxLength={50}
+          value={formData.season}
+          onChange={handleChange}
+          className="template-form-input"
+        />
+
+        <label className="template-modal-label">Діє з:</label>
+        <input
+          type="date"
+          name="valid_from"
+          value={formData.valid_from}
+          onChange={handleChange}
+          className="template-form-input"
+        />
+
+        <label className="template-modal-label">Діє до (включно):</label>
+        <input
+          type="date"
+          name="valid_to"
+          value={formData.valid_to}
+          onChange={handleChange}
+          className="template-form-input"
+        />
+
         <div className="template-modal-buttons">
           <button onClick={onHide} className="tmpl-cancel-modal">Скасування</button>
           <button onClick={handleSubmit} className="tmpl-success-modal">Зберегти</button>